@@ -3,12 +3,15 @@ module yt-transcribe
 go 1.25.5
 
 require github.com/joho/godotenv v1.5.1
-require github.com/infisical/go-sdk v0.7.1
+
+require (
+	github.com/infisical/go-sdk v0.7.1
+	github.com/jackc/pgx/v5 v5.9.1
+)
 
 require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
-	github.com/jackc/pgx/v5 v5.9.1 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/text v0.29.0 // indirect
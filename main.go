@@ -3,12 +3,18 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	api "yt-transcribe/pkg/api"
 	"yt-transcribe/pkg/bootstrap"
@@ -17,20 +23,43 @@ import (
 )
 
 const (
-	DEFAULT_VIDEO_URL  = "https://www.youtube.com/watch?v=rdWZo5PD9Ek"
-	URL_FLAG           = "url"
-	OUTPUT_FLAG        = "output"
-	DB_FLAG            = "db"
-	REPROCESS_ALL_FLAG = "reprocess-all"
-	COOKIES_FILE_FLAG  = "cookies-file"
+	DEFAULT_VIDEO_URL    = "https://www.youtube.com/watch?v=rdWZo5PD9Ek"
+	URL_FLAG             = "url"
+	OUTPUT_FLAG          = "output"
+	DB_FLAG              = "db"
+	REPROCESS_ALL_FLAG   = "reprocess-all"
+	COOKIES_FILE_FLAG    = "cookies-file"
 	COOKIES_BROWSER_FLAG = "cookies-from-browser"
+	COOKIES_DIR_FLAG     = "cookies-dir"
+	GC_FLAG              = "gc"
+	GC_CONFIRM_FLAG      = "gc-confirm"
+	SEMANTIC_SEARCH_FLAG = "semantic-search"
+
+	// Filters and dry run of -reprocess-all
+	PLATFORM_FLAG = "platform"
+	IDS_FLAG      = "ids"
+	SINCE_FLAG    = "since"
+	UNTIL_FLAG    = "until"
+	MODEL_FLAG    = "model"
+	TOOL_FLAG     = "tool"
+	STATUS_FLAG   = "status"
+	DRY_RUN_FLAG  = "dry-run"
+
+	// Batches of -reprocess-all
+	RESUME_FLAG          = "resume"
+	FAILED_IN_BATCH_FLAG = "failed-in-batch"
+
+	// DRY_RUN_SAMPLE_SIZE is the number of matching rows a -reprocess-all dry run prints.
+	DRY_RUN_SAMPLE_SIZE = 10
+
+	// MAX_DB_ATTEMPTS is the number of failed attempts after which a row is marked failed for good.
+	MAX_DB_ATTEMPTS = 5
+	// DB_RETRY_BASE_DELAY is the delay before the first retry of a row; it doubles with every attempt.
+	DB_RETRY_BASE_DELAY = 15 * time.Minute
+	// DB_RETRY_MAX_DELAY caps the delay between retries of a row.
+	DB_RETRY_MAX_DELAY = 24 * time.Hour
 )
 
-type healthResponse struct {
-	Name   string `json:"name"`
-	Status string `json:"status"`
-}
-
 // handleFatalError logs a fatal error and exits the program.
 func handleFatalError(message string, err error) {
 	if err != nil {
@@ -56,8 +85,36 @@ func runCLI() {
 	reprocessAll := flag.Bool(REPROCESS_ALL_FLAG, false, "Re-transcribe every record in the database, overwriting existing transcript URLs")
 	cookiesFile := flag.String(COOKIES_FILE_FLAG, "", "Path to a cookies file for yt-dlp")
 	cookiesFromBrowser := flag.String(COOKIES_BROWSER_FLAG, "", "Browser name to extract cookies from (e.g., chrome, firefox)")
+	cookiesDir := flag.String(COOKIES_DIR_FLAG, "", "Directory of cookies files (*.txt) for yt-dlp to rotate through")
+	gc := flag.Bool(GC_FLAG, false, "Report stored objects that no database row refers to (dry run)")
+	gcConfirm := flag.Bool(GC_CONFIRM_FLAG, false, "With -gc, delete the orphaned objects")
+	semanticQuery := flag.String(SEMANTIC_SEARCH_FLAG, "", "Print the transcript passages closest in meaning to this query")
+	platforms := flag.String(PLATFORM_FLAG, "", "With -reprocess-all, only rows of these comma-separated platforms")
+	ids := flag.String(IDS_FLAG, "", "With -reprocess-all, only rows with these comma-separated ids")
+	since := flag.String(SINCE_FLAG, "", "With -reprocess-all, only rows created at or after this date (YYYY-MM-DD or RFC 3339)")
+	until := flag.String(UNTIL_FLAG, "", "With -reprocess-all, only rows created before the end of this date (YYYY-MM-DD) or before this time (RFC 3339)")
+	model := flag.String(MODEL_FLAG, "", "With -reprocess-all, only rows transcribed with this whisper model (e.g. ggml-base.en.bin)")
+	tool := flag.String(TOOL_FLAG, "", "With -reprocess-all, only rows transcribed with this tool version (name=version, e.g. yt-dlp=2025.01.15)")
+	status := flag.String(STATUS_FLAG, "", "With -reprocess-all, only rows with this transcript_status (completed, retry, failed or skipped)")
+	failedInBatch := flag.Int64(FAILED_IN_BATCH_FLAG, 0, "With -reprocess-all, only rows that failed in this batch")
+	dryRun := flag.Bool(DRY_RUN_FLAG, false, "With -reprocess-all, print the number of matching rows and a sample instead of reprocessing")
+	resume := flag.Bool(RESUME_FLAG, false, "With -reprocess-all, continue the latest unfinished batch")
 	flag.Parse()
 
+	filter, err := reprocessFilterFromFlags(*platforms, *ids, *since, *until, *model, *tool, *status, *failedInBatch)
+	if err != nil {
+		handleFatalError("Invalid reprocess filter", err)
+	}
+	if (!filter.IsEmpty() || *dryRun || *resume) && !*reprocessAll {
+		handleFatalError(fmt.Sprintf("Filters, -%s and -%s require -%s", DRY_RUN_FLAG, RESUME_FLAG, REPROCESS_ALL_FLAG), nil)
+	}
+	if *resume && !filter.IsEmpty() {
+		handleFatalError(fmt.Sprintf("-%s continues the batch with its original filter; drop the filter flags", RESUME_FLAG), nil)
+	}
+
+	if *cookiesDir != "" {
+		os.Setenv("YT_DLP_COOKIES_DIR", *cookiesDir)
+	}
 	if *cookiesFile != "" {
 		os.Setenv("YT_DLP_COOKIES_FILE", *cookiesFile)
 	}
@@ -65,20 +122,33 @@ func runCLI() {
 		os.Setenv("YT_DLP_COOKIES_FROM_BROWSER", *cookiesFromBrowser)
 	}
 
+	// Cancel running jobs on Ctrl+C / docker stop so their working directories are cleaned up.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Searching and collecting garbage never transcribe, so they build only what they use.
+	if *semanticQuery != "" {
+		runSemanticSearch(ctx, *semanticQuery)
+		return
+	}
+	if *gc {
+		runGC(ctx, *gcConfirm)
+		return
+	}
+
 	transcriptionService, err := bootstrap.NewTranscriptionServiceFromEnv()
 	if err != nil {
 		handleFatalError("Failed to initialize transcription service", err)
 	}
 
-	ctx := context.Background()
-
 	// Ensure the output directory exists
 	if err := os.MkdirAll(*outputDir, 0755); err != nil {
 		handleFatalError(fmt.Sprintf("Error creating output directory %s", *outputDir), err)
 	}
+	sweepOrphanedDirs(*outputDir)
 
 	if *reprocessAll {
-		runReprocessAll(ctx, transcriptionService, *outputDir)
+		runReprocessAll(ctx, transcriptionService, *outputDir, filter, *resume, *dryRun)
 	} else if *useDB {
 		runFromDB(ctx, transcriptionService, *outputDir)
 	} else {
@@ -87,37 +157,65 @@ func runCLI() {
 }
 
 func runServer(port string) {
-	transcriptionService, err := bootstrap.NewTranscriptionServiceFromEnv()
+	cfg, err := bootstrap.LoadConfigFromEnv(context.Background())
 	if err != nil {
+		handleFatalError("Failed to load configuration", err)
+	}
+	if err := cfg.CheckTranscription(); err != nil {
 		handleFatalError("Failed to initialize transcription service", err)
 	}
+	runtime := bootstrap.NewRuntime(context.Background(), cfg)
+	transcriptionService := bootstrap.NewTranscriptionService(cfg, runtime)
+
+	sweepOrphanedDirs(os.TempDir())
 
 	mux := http.NewServeMux()
 	mux.Handle("/api/transcribe", api.NewTranscribeHandler(transcriptionService))
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return
-		}
-		if r.Method != http.MethodGet {
-			w.Header().Set("Allow", http.MethodGet)
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
+	search := api.NewSearchHandler(nil)
+	semanticSearch := api.NewSemanticSearchHandler(nil)
+	if cfg.PostgresURL != "" {
+		repo, err := repository.NewPostgresMediaItemRepository(context.Background(), cfg.PostgresURL)
+		if err != nil {
+			handleFatalError("Failed to connect to database", err)
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(healthResponse{
-			Name:   "yt-transcribe",
-			Status: "ok",
-		}); err != nil {
-			http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+		defer repo.Close(context.Background())
+		search = api.NewSearchHandler(repo).WithDeepLinks(src.DeepLink)
+		if embedder := bootstrap.NewEmbedder(cfg); embedder != nil {
+			semanticSearch = api.NewSemanticSearchHandler(&src.SemanticSearcher{Embedder: embedder, Store: repo, Model: cfg.EmbeddingsModel}).WithDeepLinks(src.DeepLink)
+		} else {
+			log.Printf("EMBEDDINGS_URL not set — /api/semantic-search is disabled")
 		}
-	})
+	} else {
+		log.Printf("POSTGRES_URL not set — /api/search and /api/semantic-search are disabled")
+	}
+	mux.Handle("/api/search", search)
+	mux.Handle("/api/semantic-search", semanticSearch)
+	health := api.NewHealthHandler(src.APP_NAME, []string{os.TempDir()}, cfg.MinFreeSpace).WithToolVersions(runtime.Tools)
+	if runtime.Cookies != nil {
+		health.WithCookieStats(runtime.Cookies.Stats)
+	}
+	mux.Handle("/", health)
+	if cfg.StorageBackend == bootstrap.STORAGE_BACKEND_FILESYSTEM {
+		// Serve locally stored artifacts so LOCAL_STORAGE_BASE_URL can point at http://host:port/files.
+		mux.Handle("/files/", http.StripPrefix("/files/", http.FileServer(http.Dir(cfg.LocalStorageRoot))))
+	}
 
 	log.Printf("Starting HTTP server on :%s", port)
 	handleFatalError("HTTP server stopped", http.ListenAndServe(":"+port, mux))
 }
 
+// sweepOrphanedDirs removes job and API request directories left behind in dir by crashed runs.
+func sweepOrphanedDirs(dir string) {
+	removed, err := src.SweepStaleDirs(dir, src.JOB_DIR_MAX_AGE, src.JOB_DIR_PREFIX, api.TEMP_DIR_PREFIX)
+	if err != nil {
+		log.Printf("Warning: could not sweep orphaned job directories in %s: %v", dir, err)
+		return
+	}
+	for _, path := range removed {
+		log.Printf("Removed orphaned job directory: %s", path)
+	}
+}
+
 // runFromCLI processes a single URL provided via flags or positional args.
 func runFromCLI(ctx context.Context, svc src.TranscriptionService, videoURL, outputDir string) {
 	if videoURL == "" {
@@ -142,7 +240,7 @@ func runFromCLI(ctx context.Context, svc src.TranscriptionService, videoURL, out
 }
 
 // runFromDB fetches the next unprocessed media_items row, transcribes it,
-// and writes the resulting Vercel Blob URL back to transcript_url.
+// and writes the resulting transcript URL back to transcript_url.
 func runFromDB(ctx context.Context, svc src.TranscriptionService, outputDir string) {
 	cfg, err := bootstrap.LoadConfigFromEnv(ctx)
 	if err != nil {
@@ -172,22 +270,193 @@ func runFromDB(ctx context.Context, svc src.TranscriptionService, outputDir stri
 	fmt.Printf("Fetched item from DB — id: %s  platform: %s  url: %s\n", item.ID, item.Platform, item.URL)
 	fmt.Printf("Output directory: %s\n", outputDir)
 
-	blobURL, err := svc.Execute(ctx, item.URL, outputDir)
+	jobCtx, attemptLog := src.WithAttemptLog(ctx)
+	result, err := svc.Run(jobCtx, item.URL, outputDir)
+	recordAttempts(ctx, repo, item.ID, attemptLog)
 	if err != nil {
-		handleFatalError("Error executing transcription service", err)
+		log.Printf("Error executing transcription service: %v", err)
+		recordFailure(ctx, repo, item, err)
+		return
 	}
 
-	if err := repo.UpdateTranscriptURL(ctx, item.ID, blobURL); err != nil {
+	if err := repo.UpdateTranscript(ctx, item.ID, transcriptRecord(result)); err != nil {
 		handleFatalError("Transcription succeeded but failed to update transcript_url in database", err)
 	}
+	newTranscriptIndexer(cfg).index(ctx, repo, item.ID, result)
 
 	fmt.Printf("transcript_url updated in database for id %s\n", item.ID)
 }
 
-// runReprocessAll fetches every record in media_items and re-transcribes each one,
-// overwriting the existing transcript_url. Failures on individual items are logged
-// and skipped so the rest of the batch can continue.
-func runReprocessAll(ctx context.Context, svc src.TranscriptionService, outputDir string) {
+// recordFailure stores the outcome of a failed job on its media_items row. Videos rejected by the
+// pre-flight checks are marked skipped. Permanent failures
+// (e.g. a private or removed video) and rows that have exhausted MAX_DB_ATTEMPTS are marked
+// failed; everything else is scheduled for a retry with exponential backoff.
+func recordFailure(ctx context.Context, repo repository.MediaItemRepository, item *repository.MediaItem, jobErr error) {
+	if errors.Is(jobErr, src.ErrRejected) {
+		if err := repo.MarkSkipped(ctx, item.ID, jobErr.Error()); err != nil {
+			log.Printf("Warning: could not mark id %s as skipped: %v", item.ID, err)
+			return
+		}
+		fmt.Printf("id %s skipped: %v\n", item.ID, jobErr)
+		return
+	}
+
+	if src.IsPermanent(jobErr) || item.Attempts+1 >= MAX_DB_ATTEMPTS {
+		if err := repo.MarkFailed(ctx, item.ID, jobErr.Error()); err != nil {
+			log.Printf("Warning: could not mark id %s as failed: %v", item.ID, err)
+			return
+		}
+		fmt.Printf("id %s marked as permanently failed\n", item.ID)
+		return
+	}
+
+	retryAt := time.Now().Add(dbRetryDelay(item.Attempts))
+	if err := repo.ScheduleRetry(ctx, item.ID, jobErr.Error(), retryAt); err != nil {
+		log.Printf("Warning: could not schedule retry for id %s: %v", item.ID, err)
+		return
+	}
+	fmt.Printf("id %s scheduled for retry at %s\n", item.ID, retryAt.Format(time.RFC3339))
+}
+
+// transcriptRecord converts a job result into the values stored on its media_items row.
+func transcriptRecord(result *src.Result) repository.Transcript {
+	provenance, err := json.Marshal(result.Provenance)
+	if err != nil {
+		log.Printf("Warning: could not encode transcript provenance: %v", err)
+		provenance = nil
+	}
+	return repository.Transcript{URL: result.URL, Provenance: provenance, SHA256: result.SHA256, AudioURL: result.AudioURL, NotesURL: result.NotesURL}
+}
+
+// transcriptIndexer stores the content of successful transcripts for search: the text and segments
+// for full-text search and, when an embedder is configured, embedded passages for semantic search.
+type transcriptIndexer struct {
+	embedder     src.Embedder
+	model        string
+	passageChars int
+}
+
+// newTranscriptIndexer returns the indexer configured by cfg.
+func newTranscriptIndexer(cfg *bootstrap.Config) *transcriptIndexer {
+	return &transcriptIndexer{embedder: bootstrap.NewEmbedder(cfg), model: cfg.EmbeddingsModel, passageChars: cfg.PassageChars}
+}
+
+// index stores the transcript of result for the row id, as far as repo supports it. The transcript
+// is already stored, so failures are only logged.
+func (ix *transcriptIndexer) index(ctx context.Context, repo repository.MediaItemRepository, id string, result *src.Result) {
+	searcher, ok := repo.(repository.TranscriptSearcher)
+	if !ok {
+		return
+	}
+	segments, err := src.ParseSRT(result.Transcript)
+	if err != nil {
+		log.Printf("Warning: could not parse transcript of id %s for search: %v", id, err)
+		return
+	}
+	text := repository.TranscriptText{Text: src.SegmentsText(segments), Segments: make([]repository.Segment, len(segments))}
+	for i, segment := range segments {
+		text.Segments[i] = repository.Segment(segment)
+	}
+	if err := searcher.SaveTranscriptText(ctx, id, text); err != nil {
+		log.Printf("Warning: could not store transcript text of id %s for search: %v", id, err)
+	}
+
+	store, ok := repo.(repository.PassageStore)
+	if ix.embedder == nil || !ok {
+		return
+	}
+	if result.Unchanged {
+		// The stored passages were embedded from the same transcript
+		embedded, err := store.HasPassages(ctx, id, ix.model)
+		if err != nil {
+			log.Printf("Warning: could not check passages of id %s: %v", id, err)
+			return
+		}
+		if embedded {
+			return
+		}
+	}
+	chunks := src.ChunkPassages(segments, ix.passageChars)
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+	vectors, err := ix.embedder.Embed(ctx, texts)
+	if err != nil {
+		log.Printf("Warning: could not embed transcript of id %s: %v", id, err)
+		return
+	}
+	passages := make([]repository.Passage, len(chunks))
+	for i, chunk := range chunks {
+		passages[i] = repository.Passage{Start: chunk.Start, End: chunk.End, Text: chunk.Text, Embedding: vectors[i]}
+	}
+	if err := store.SavePassages(ctx, id, ix.model, passages); err != nil {
+		log.Printf("Warning: could not store passages of id %s: %v", id, err)
+	}
+}
+
+// indexJob is a stored transcript waiting to be indexed.
+type indexJob struct {
+	id     string
+	result *src.Result
+}
+
+// start indexes the transcripts sent to the returned queue on workers goroutines, so that the
+// embedding requests do not hold up the caller. Closing the queue and calling wait lets the queued
+// transcripts finish.
+func (ix *transcriptIndexer) start(ctx context.Context, repo repository.MediaItemRepository, workers, queueSize int) (queue chan<- indexJob, wait func()) {
+	jobs := make(chan indexJob, queueSize)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				ix.index(ctx, repo, job.id, job.result)
+			}
+		}()
+	}
+	return jobs, wg.Wait
+}
+
+// recordAttempts stores the failed stage attempts collected in attemptLog for the row id.
+func recordAttempts(ctx context.Context, repo repository.MediaItemRepository, id string, attemptLog *src.AttemptLog) {
+	attempts := attemptLog.Attempts()
+	if len(attempts) == 0 {
+		return
+	}
+
+	jobAttempts := make([]repository.JobAttempt, 0, len(attempts))
+	for _, a := range attempts {
+		jobAttempts = append(jobAttempts, repository.JobAttempt{
+			Stage:   a.Stage,
+			Attempt: a.Number,
+			Error:   a.Err.Error(),
+			At:      a.At,
+		})
+	}
+	if err := repo.RecordAttempts(ctx, id, jobAttempts); err != nil {
+		log.Printf("Warning: could not record attempts for id %s: %v", id, err)
+	}
+}
+
+// dbRetryDelay returns the backoff before the next attempt of a row that has already failed attempts times.
+func dbRetryDelay(attempts int) time.Duration {
+	delay := DB_RETRY_BASE_DELAY
+	for i := 0; i < attempts && delay < DB_RETRY_MAX_DELAY; i++ {
+		delay *= 2
+	}
+	return min(delay, DB_RETRY_MAX_DELAY)
+}
+
+// runReprocessAll re-transcribes the records in media_items matched by filter, overwriting the
+// existing transcript_url. Items go through the service's pipeline (cfg.Pipeline), so downloads
+// overlap with transcription. The run is recorded as a reprocess batch: the outcome of every item is
+// stored and the batch cursor advances past it, so that resume can continue the latest unfinished
+// batch after an interruption. Failures on individual items are logged and skipped so the rest of
+// the batch can continue. With dryRun set, it only prints the number of matching records and a
+// sample of them.
+func runReprocessAll(ctx context.Context, svc src.TranscriptionService, outputDir string, filter repository.ReprocessFilter, resume, dryRun bool) {
 	cfg, err := bootstrap.LoadConfigFromEnv(ctx)
 	if err != nil {
 		handleFatalError("Failed to load configuration", err)
@@ -204,39 +473,319 @@ func runReprocessAll(ctx context.Context, svc src.TranscriptionService, outputDi
 	}
 	defer repo.Close(ctx)
 
-	items, err := repo.FetchAll(ctx)
-	if err != nil {
-		handleFatalError("Failed to fetch all items from database", err)
+	var batch *repository.Batch
+	if resume {
+		if batch, err = repo.LatestUnfinishedBatch(ctx); err != nil {
+			handleFatalError("Failed to fetch unfinished batch from database", err)
+		}
+		if batch == nil {
+			fmt.Println("No unfinished batch to resume. Nothing to do.")
+			return
+		}
+		filter = batch.Filter
+		filter.After = batch.Cursor
 	}
-	if len(items) == 0 {
-		fmt.Println("No records found in the database. Nothing to do.")
+
+	if dryRun {
+		printReprocessSample(ctx, repo, filter)
 		return
 	}
 
-	total := len(items)
-	succeeded, failed := 0, 0
+	if batch == nil {
+		total, err := repo.CountMatching(ctx, filter)
+		if err != nil {
+			handleFatalError("Failed to count items in database", err)
+		}
+		if total == 0 {
+			fmt.Println("No matching records found in the database. Nothing to do.")
+			return
+		}
+		if batch, err = repo.CreateBatch(ctx, filter, total); err != nil {
+			handleFatalError("Failed to create batch", err)
+		}
+		fmt.Printf("Reprocessing %d record(s) as batch %d...\n\n", total, batch.ID)
+	} else {
+		fmt.Printf("Resuming batch %d: %d of %d record(s) already processed...\n\n", batch.ID, batch.Processed, batch.Total)
+	}
 
-	fmt.Printf("Reprocessing %d record(s)...\n\n", total)
+	// Items are drawn from the database as the pipeline has room for them.
+	var iterErr error
+	drawn := 0
+	jobs := func(yield func(src.PipelineJob) bool) {
+		for item, err := range repository.Iterate(ctx, repo, filter, repository.DEFAULT_PAGE_SIZE) {
+			if err != nil {
+				iterErr = err
+				return
+			}
+			drawn++
+			fmt.Printf("[%d/%d] id: %s  platform: %s  url: %s\n", batch.Processed+drawn, max(batch.Total, batch.Processed+drawn), item.ID, item.Platform, item.URL)
+			if !yield(reprocessJob(ctx, item)) {
+				return
+			}
+		}
+	}
 
-	for i, item := range items {
-		fmt.Printf("[%d/%d] id: %s  platform: %s  url: %s\n", i+1, total, item.ID, item.Platform, item.URL)
+	// Transcripts are indexed beside the pipeline, on as many workers as it has uploaders.
+	index, waitIndexed := newTranscriptIndexer(cfg).start(ctx, repo, cfg.Pipeline.Uploaders, cfg.Pipeline.QueueSize)
+	// Results arrive in the order the items were drawn, so the batch cursor only ever moves past
+	// items whose outcome has been recorded.
+	outcomes := map[string]int{}
+	processed := 0
+	interrupted := false
+	report := func(r src.PipelineResult) {
+		if interrupted {
+			return
+		}
+		pending := r.Job.Key.(*reprocessPending)
+		if ctx.Err() != nil && r.Err != nil {
+			// Cancelled mid-job: leave the item for -resume instead of recording it as failed.
+			interrupted = true
+			return
+		}
+		outcome := recordReprocessResult(ctx, repo, index, pending, r.Result, r.Err)
+		processed++
+		outcomes[outcome.Status]++
+		if err := repo.RecordBatchItem(ctx, batch.ID, outcome); err != nil {
+			log.Printf("  ✗ batch progress not recorded: %v\n", err)
+		}
+	}
 
-		blobURL, err := svc.Execute(ctx, item.URL, outputDir)
+	if runner, ok := svc.(src.PipelineRunner); ok {
+		metrics, err := runner.RunPipeline(ctx, jobs, outputDir, cfg.Pipeline, report)
 		if err != nil {
-			log.Printf("  ✗ transcription failed: %v — skipping\n", err)
-			failed++
-			continue
+			handleFatalError("Failed to start the reprocessing pipeline", err)
 		}
+		fmt.Printf("\nPipeline: %s\n", metrics)
+	} else {
+		for job := range jobs {
+			result, err := svc.Run(job.Context, job.VideoURL, outputDir)
+			report(src.PipelineResult{Job: job, Result: result, Err: err})
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	close(index)
+	waitIndexed()
+
+	fmt.Printf("\nDone. %d succeeded, %d unchanged, %d failed, %d skipped out of %d processed.\n",
+		outcomes[repository.BATCH_ITEM_SUCCEEDED], outcomes[repository.BATCH_ITEM_UNCHANGED],
+		outcomes[repository.BATCH_ITEM_FAILED], outcomes[repository.BATCH_ITEM_SKIPPED], processed)
+	if iterErr != nil || ctx.Err() != nil {
+		if iterErr != nil {
+			log.Printf("Failed to fetch items from database: %v", iterErr)
+		} else {
+			log.Printf("Interrupted — stopping after %d record(s)", processed)
+		}
+		fmt.Printf("Batch %d is unfinished. Continue it with -%s -%s.\n", batch.ID, REPROCESS_ALL_FLAG, RESUME_FLAG)
+		return
+	}
+	if err := repo.FinishBatch(ctx, batch.ID); err != nil {
+		log.Printf("Failed to mark batch %d finished: %v", batch.ID, err)
+	}
+	if n := outcomes[repository.BATCH_ITEM_FAILED]; n > 0 {
+		fmt.Printf("Re-run the %d failed record(s) with -%s -%s %d.\n", n, REPROCESS_ALL_FLAG, FAILED_IN_BATCH_FLAG, batch.ID)
+	}
+}
+
+// reprocessPending is the state of a record between submitting its job and recording its result.
+type reprocessPending struct {
+	item       repository.MediaItem
+	attemptLog *src.AttemptLog
+}
+
+// reprocessJob returns the pipeline job that re-transcribes item, reusing its stored transcript
+// hash and archived audio.
+func reprocessJob(ctx context.Context, item repository.MediaItem) src.PipelineJob {
+	jobCtx, attemptLog := src.WithAttemptLog(ctx)
+	jobCtx = src.WithStoredTranscript(jobCtx, src.StoredTranscript{URL: item.TranscriptURL, SHA256: item.TranscriptSHA256, NotesURL: item.NotesURL})
+	if item.AudioURL != "" {
+		jobCtx = src.WithArchivedAudio(jobCtx, item.AudioURL)
+	}
+	return src.PipelineJob{VideoURL: item.URL, Context: jobCtx, Key: &reprocessPending{item: item, attemptLog: attemptLog}}
+}
+
+// recordReprocessResult writes the new transcript of a record back to its row, together with the
+// failed attempts, and queues it on index for search. The returned BatchItem reports the outcome for
+// the batch.
+func recordReprocessResult(ctx context.Context, repo repository.MediaItemRepository, index chan<- indexJob, pending *reprocessPending, result *src.Result, err error) repository.BatchItem {
+	item := pending.item
+	outcome := repository.BatchItem{MediaItemID: item.ID, CreatedAt: item.CreatedAt}
+
+	recordAttempts(ctx, repo, item.ID, pending.attemptLog)
+	if errors.Is(err, src.ErrRejected) {
+		log.Printf("  - %s rejected: %v — skipping\n", item.ID, err)
+		outcome.Status, outcome.Error = repository.BATCH_ITEM_SKIPPED, err.Error()
+		return outcome
+	}
+	if err != nil {
+		log.Printf("  ✗ %s transcription failed: %v — skipping\n", item.ID, err)
+		outcome.Status, outcome.Error = repository.BATCH_ITEM_FAILED, err.Error()
+		return outcome
+	}
+
+	if err := repo.UpdateTranscript(ctx, item.ID, transcriptRecord(result)); err != nil {
+		log.Printf("  ✗ %s db update failed: %v — skipping\n", item.ID, err)
+		outcome.Status, outcome.Error = repository.BATCH_ITEM_FAILED, err.Error()
+		return outcome
+	}
+	index <- indexJob{id: item.ID, result: result}
+
+	if result.Unchanged {
+		fmt.Printf("  = %s transcript unchanged, upload skipped\n", item.ID)
+		outcome.Status = repository.BATCH_ITEM_UNCHANGED
+		return outcome
+	}
+	fmt.Printf("  ✓ %s transcript_url updated\n", item.ID)
+	outcome.Status = repository.BATCH_ITEM_SUCCEEDED
+	return outcome
+}
+
+// printReprocessSample prints how many records filter matches and the first DRY_RUN_SAMPLE_SIZE of them.
+func printReprocessSample(ctx context.Context, repo repository.MediaItemRepository, filter repository.ReprocessFilter) {
+	count, err := repo.CountMatching(ctx, filter)
+	if err != nil {
+		handleFatalError("Failed to count items in database", err)
+	}
+	filter.Limit = DRY_RUN_SAMPLE_SIZE
+	sample, err := repo.FetchMatching(ctx, filter)
+	if err != nil {
+		handleFatalError("Failed to fetch items from database", err)
+	}
+
+	fmt.Printf("%d record(s) match.\n", count)
+	for _, item := range sample {
+		fmt.Printf("  id: %s  platform: %s  created: %s  url: %s\n", item.ID, item.Platform, item.CreatedAt.Format(time.RFC3339), item.URL)
+	}
+	if count > len(sample) {
+		fmt.Printf("  ... and %d more\n", count-len(sample))
+	}
+	fmt.Println("Dry run: nothing was reprocessed.")
+}
 
-		if err := repo.UpdateTranscriptURL(ctx, item.ID, blobURL); err != nil {
-			log.Printf("  ✗ db update failed: %v — skipping\n", err)
-			failed++
-			continue
+// reprocessFilterFromFlags builds the -reprocess-all filter from the filter flags. A date-only
+// until includes the whole day.
+func reprocessFilterFromFlags(platforms, ids, since, until, model, tool, status string, failedInBatch int64) (repository.ReprocessFilter, error) {
+	filter := repository.ReprocessFilter{
+		Platforms:     bootstrap.SplitList(platforms),
+		IDs:           bootstrap.SplitList(ids),
+		Model:         model,
+		Status:        status,
+		FailedInBatch: failedInBatch,
+	}
+	if tool != "" {
+		name, version, _ := strings.Cut(tool, "=")
+		filter.ToolName, filter.ToolVersion = name, version
+	}
+
+	var err error
+	if since != "" {
+		if filter.CreatedFrom, err = repository.ParseDateBound(since, false); err != nil {
+			return filter, fmt.Errorf("-%s: %w", SINCE_FLAG, err)
 		}
+	}
+	if until != "" {
+		if filter.CreatedTo, err = repository.ParseDateBound(until, true); err != nil {
+			return filter, fmt.Errorf("-%s: %w", UNTIL_FLAG, err)
+		}
+	}
+	return filter, filter.Validate()
+}
+
+// runSemanticSearch prints the DEFAULT_SEMANTIC_LIMIT stored passages nearest to query, with links
+// that open the videos at the passages.
+func runSemanticSearch(ctx context.Context, query string) {
+	cfg, err := bootstrap.LoadConfigFromEnv(ctx)
+	if err != nil {
+		handleFatalError("Failed to load configuration", err)
+	}
+	if cfg.PostgresURL == "" {
+		handleFatalError(fmt.Sprintf("POSTGRES_URL not set (required for -%s)", SEMANTIC_SEARCH_FLAG), nil)
+	}
+	embedder := bootstrap.NewEmbedder(cfg)
+	if embedder == nil {
+		handleFatalError(fmt.Sprintf("EMBEDDINGS_URL not set (required for -%s)", SEMANTIC_SEARCH_FLAG), nil)
+	}
 
-		fmt.Printf("  ✓ transcript_url updated\n")
-		succeeded++
+	repo, err := repository.NewPostgresMediaItemRepository(ctx, cfg.PostgresURL)
+	if err != nil {
+		handleFatalError("Failed to connect to database", err)
 	}
+	defer repo.Close(ctx)
 
-	fmt.Printf("\nDone. %d succeeded, %d failed out of %d total.\n", succeeded, failed, total)
+	searcher := &src.SemanticSearcher{Embedder: embedder, Store: repo, Model: cfg.EmbeddingsModel}
+	matches, err := searcher.Search(ctx, query, repository.DEFAULT_SEMANTIC_LIMIT)
+	if err != nil {
+		handleFatalError("Semantic search failed", err)
+	}
+	if len(matches) == 0 {
+		fmt.Printf("No passages embedded with %s found.\n", cfg.EmbeddingsModel)
+		return
+	}
+	for i, match := range matches {
+		item := match.Item
+		fmt.Printf("%d. %s at %s (distance %.3f)\n   %s\n   %s\n\n", i+1, match.Title, formatTimestamp(match.Passage.Start), match.Distance,
+			src.DeepLink(item.Platform, item.VideoID, item.URL, match.Passage.Start), match.Passage.Text)
+	}
+}
+
+// formatTimestamp formats an offset into a video as m:ss, or h:mm:ss from one hour on.
+func formatTimestamp(d time.Duration) string {
+	seconds := int(d.Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// runGC lists the objects below APP_NAME/ in the storage backend and reports those that no
+// transcript_url or notes_url refers to. Orphans are only deleted when confirm is set.
+func runGC(ctx context.Context, confirm bool) {
+	cfg, err := bootstrap.LoadConfigFromEnv(ctx)
+	if err != nil {
+		handleFatalError("Failed to load configuration", err)
+	}
+	if cfg.PostgresURL == "" {
+		handleFatalError("POSTGRES_URL not set (required for -gc mode)", nil)
+	}
+
+	store, err := bootstrap.NewObjectStore(cfg)
+	if err != nil {
+		handleFatalError("Failed to open storage backend", err)
+	}
+
+	repo, err := repository.NewPostgresMediaItemRepository(ctx, cfg.PostgresURL)
+	if err != nil {
+		handleFatalError("Failed to connect to database", err)
+	}
+	defer repo.Close(ctx)
+
+	urls, err := repo.ListArtifactURLs(ctx)
+	if err != nil {
+		handleFatalError("Failed to fetch artifact URLs from database", err)
+	}
+
+	report, err := src.CollectGarbage(ctx, store, urls, src.GCOptions{
+		Prefix: src.APP_NAME + "/",
+		MinAge: src.GC_MIN_AGE,
+		Delete: confirm,
+	})
+	if err != nil {
+		handleFatalError("Garbage collection failed", err)
+	}
+
+	for _, obj := range report.Orphans {
+		fmt.Printf("orphan: %s  (%d bytes, %s)\n", obj.Pathname, obj.Size, obj.LastModified.Format(time.RFC3339))
+	}
+	for _, err := range report.Errors {
+		log.Printf("  ✗ %v", err)
+	}
+	fmt.Printf("\nScanned %d object(s) against %d database URL(s): %d orphan(s), %d bytes.\n",
+		report.Scanned, len(urls), len(report.Orphans), report.OrphanBytes())
+	if confirm {
+		fmt.Printf("Deleted %d, failed %d.\n", report.Deleted, len(report.Errors))
+	} else if len(report.Orphans) > 0 {
+		fmt.Printf("Dry run: nothing was deleted. Re-run with -%s -%s to delete the orphans.\n", GC_FLAG, GC_CONFIRM_FLAG)
+	}
 }
@@ -0,0 +1,12 @@
+-- Tracks the outcome of transcription attempts so the -db worker can tell
+-- permanently failed rows (private, removed, geo-blocked, ...) apart from rows
+-- that hit a transient error and should be retried later.
+ALTER TABLE media_items
+  ADD COLUMN IF NOT EXISTS transcript_status   TEXT,
+  ADD COLUMN IF NOT EXISTS transcript_error    TEXT,
+  ADD COLUMN IF NOT EXISTS transcript_attempts INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS next_attempt_at     TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS media_items_transcript_queue_idx
+  ON media_items (created_at)
+  WHERE transcript_url IS NULL;
@@ -0,0 +1,13 @@
+-- One row per failed attempt of a pipeline stage (download, transcribe, upload),
+-- written by the -db and -reprocess-all workers.
+CREATE TABLE IF NOT EXISTS transcription_attempts (
+  id            BIGSERIAL   PRIMARY KEY,
+  media_item_id TEXT        NOT NULL REFERENCES media_items (id) ON DELETE CASCADE,
+  stage         TEXT        NOT NULL,
+  attempt       INTEGER     NOT NULL,
+  error         TEXT        NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS transcription_attempts_media_item_idx
+  ON transcription_attempts (media_item_id, created_at);
@@ -0,0 +1,4 @@
+-- Provenance of the current transcript: whisper model, tool versions (yt-dlp, ffmpeg, whisper-cli)
+-- and transcription time, written together with transcript_url by the -db and -reprocess-all workers.
+ALTER TABLE media_items
+  ADD COLUMN IF NOT EXISTS transcript_provenance JSONB;
@@ -0,0 +1,4 @@
+-- SHA-256 of the current transcript content, written together with transcript_url.
+-- -reprocess-all compares it with the new transcript and skips the upload when they match.
+ALTER TABLE media_items
+  ADD COLUMN IF NOT EXISTS transcript_sha256 TEXT;
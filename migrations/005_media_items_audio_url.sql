@@ -0,0 +1,4 @@
+-- Opus archive of the downloaded audio, uploaded next to the transcript when ARCHIVE_AUDIO=true.
+-- -reprocess-all transcribes it instead of downloading the video again.
+ALTER TABLE media_items
+  ADD COLUMN IF NOT EXISTS audio_url TEXT;
@@ -0,0 +1,29 @@
+-- One row per -reprocess-all run. The filter is stored so that -resume can continue the last
+-- unfinished batch, and the cursor is the (created_at, id) of the last processed media item.
+CREATE TABLE IF NOT EXISTS reprocess_batches (
+  id                BIGSERIAL   PRIMARY KEY,
+  filter            JSONB       NOT NULL,
+  total             INTEGER     NOT NULL,
+  cursor_created_at TIMESTAMPTZ,
+  cursor_id         TEXT,
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at       TIMESTAMPTZ
+);
+
+-- Outcome of every item processed by a batch: succeeded, unchanged, failed or skipped.
+CREATE TABLE IF NOT EXISTS reprocess_batch_items (
+  batch_id      BIGINT      NOT NULL REFERENCES reprocess_batches (id) ON DELETE CASCADE,
+  media_item_id TEXT        NOT NULL REFERENCES media_items (id) ON DELETE CASCADE,
+  status        TEXT        NOT NULL,
+  error         TEXT,
+  processed_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (batch_id, media_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS reprocess_batch_items_status_idx
+  ON reprocess_batch_items (batch_id, status);
+
+-- Keyset order used by -reprocess-all
+CREATE INDEX IF NOT EXISTS media_items_created_at_id_idx
+  ON media_items (created_at, id);
@@ -0,0 +1,24 @@
+-- Plain text of the transcript of each media item, for full-text search.
+CREATE TABLE IF NOT EXISTS transcripts (
+  media_item_id TEXT        PRIMARY KEY REFERENCES media_items (id) ON DELETE CASCADE,
+  text          TEXT        NOT NULL,
+  search        TSVECTOR    GENERATED ALWAYS AS (to_tsvector('english', text)) STORED,
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS transcripts_search_idx
+  ON transcripts USING GIN (search);
+
+-- Timed cues of each transcript, so a match can be located in the video.
+CREATE TABLE IF NOT EXISTS transcript_segments (
+  media_item_id TEXT     NOT NULL REFERENCES media_items (id) ON DELETE CASCADE,
+  position      INTEGER  NOT NULL,
+  start_ms      INTEGER  NOT NULL,
+  end_ms        INTEGER  NOT NULL,
+  text          TEXT     NOT NULL,
+  search        TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', text)) STORED,
+  PRIMARY KEY (media_item_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS transcript_segments_search_idx
+  ON transcript_segments USING GIN (search);
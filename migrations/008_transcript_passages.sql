@@ -0,0 +1,19 @@
+-- Passages of each transcript with their embeddings, for semantic search. Requires pgvector.
+CREATE EXTENSION IF NOT EXISTS vector;
+
+-- The vector dimension depends on the embedding model, so it is not fixed here. Embeddings of
+-- different models are never compared: every query is restricted to one model.
+CREATE TABLE IF NOT EXISTS transcript_passages (
+  media_item_id TEXT        NOT NULL REFERENCES media_items (id) ON DELETE CASCADE,
+  position      INTEGER     NOT NULL,
+  start_ms      INTEGER     NOT NULL,
+  end_ms        INTEGER     NOT NULL,
+  text          TEXT        NOT NULL,
+  model         TEXT        NOT NULL,
+  embedding     VECTOR      NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (media_item_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS transcript_passages_model_idx
+  ON transcript_passages (model);
@@ -0,0 +1,14 @@
+-- Approximate nearest-neighbour indexes for semantic search. An HNSW index needs a fixed vector
+-- dimension, so there is one partial index per dimension, over the embeddings cast to it.
+-- NearestPassages casts the same way, which lets the planner use the index of the query's
+-- dimension. Add an index like these for models of other dimensions (at most 2000).
+
+-- text-embedding-3-small
+CREATE INDEX IF NOT EXISTS transcript_passages_embedding_1536_idx
+  ON transcript_passages USING hnsw ((embedding::vector(1536)) vector_cosine_ops)
+  WHERE vector_dims(embedding) = 1536;
+
+-- nomic-embed-text
+CREATE INDEX IF NOT EXISTS transcript_passages_embedding_768_idx
+  ON transcript_passages USING hnsw ((embedding::vector(768)) vector_cosine_ops)
+  WHERE vector_dims(embedding) = 768;
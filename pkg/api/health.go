@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"yt-transcribe/pkg/diskspace"
+	"yt-transcribe/pkg/downloader"
+	"yt-transcribe/pkg/toolversion"
+)
+
+const (
+	HEALTH_STATUS_OK       = "ok"
+	HEALTH_STATUS_DEGRADED = "degraded"
+)
+
+// HealthHandler serves the service status on "/", including the free space of the
+// filesystems jobs write to. The status is degraded when one of them is below the minimum,
+// when every yt-dlp cookie file is cooling down, or when an external tool could not be run.
+type HealthHandler struct {
+	name         string
+	diskPaths    []string
+	minFreeSpace uint64
+	diskUsage    func(path string) (diskspace.Usage, error)
+	cookieStats  func() []downloader.CookieStats
+	tools        []toolversion.Version
+}
+
+type healthResponse struct {
+	Name    string                   `json:"name"`
+	Status  string                   `json:"status"`
+	Disk    []diskspace.Usage        `json:"disk,omitempty"`
+	Cookies []downloader.CookieStats `json:"cookies,omitempty"`
+	Tools   []toolversion.Version    `json:"tools,omitempty"`
+}
+
+// NewHealthHandler returns a HealthHandler reporting the free space of diskPaths
+// against minFreeSpace bytes.
+func NewHealthHandler(name string, diskPaths []string, minFreeSpace uint64) *HealthHandler {
+	return &HealthHandler{
+		name:         name,
+		diskPaths:    diskPaths,
+		minFreeSpace: minFreeSpace,
+		diskUsage:    diskspace.Stat,
+	}
+}
+
+// WithCookieStats makes the handler report the per-file statistics of a yt-dlp cookie pool.
+func (h *HealthHandler) WithCookieStats(stats func() []downloader.CookieStats) *HealthHandler {
+	h.cookieStats = stats
+	return h
+}
+
+// WithToolVersions makes the handler report the versions of the external tools detected at startup.
+func (h *HealthHandler) WithToolVersions(tools []toolversion.Version) *HealthHandler {
+	h.tools = tools
+	return h
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := healthResponse{Name: h.name, Status: HEALTH_STATUS_OK}
+	for _, path := range h.diskPaths {
+		usage, err := h.diskUsage(path)
+		if err != nil {
+			log.Printf("Warning: health check could not stat %s: %v", path, err)
+			response.Status = HEALTH_STATUS_DEGRADED
+			continue
+		}
+		if usage.Free < h.minFreeSpace {
+			response.Status = HEALTH_STATUS_DEGRADED
+		}
+		response.Disk = append(response.Disk, usage)
+	}
+	response.Tools = h.tools
+	for _, tool := range h.tools {
+		if tool.Error != "" {
+			response.Status = HEALTH_STATUS_DEGRADED
+		}
+	}
+	if h.cookieStats != nil {
+		response.Cookies = h.cookieStats()
+		if !anyCookieHealthy(response.Cookies) {
+			response.Status = HEALTH_STATUS_DEGRADED
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func anyCookieHealthy(stats []downloader.CookieStats) bool {
+	for _, s := range stats {
+		if s.Healthy {
+			return true
+		}
+	}
+	return len(stats) == 0
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"yt-transcribe/pkg/diskspace"
+	"yt-transcribe/pkg/downloader"
+	"yt-transcribe/pkg/toolversion"
+)
+
+func newTestHealthHandler(free uint64, statErr error) *HealthHandler {
+	handler := NewHealthHandler("yt-transcribe", []string{"/tmp"}, 100)
+	handler.diskUsage = func(path string) (diskspace.Usage, error) {
+		if statErr != nil {
+			return diskspace.Usage{}, statErr
+		}
+		return diskspace.Usage{Path: path, Free: free, Total: 1000}, nil
+	}
+	return handler
+}
+
+func serveHealth(t *testing.T, handler http.Handler) healthResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	var response healthResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("expected valid JSON response: %v", err)
+	}
+	return response
+}
+
+func TestHealthHandler_ReportsDiskUsage(t *testing.T) {
+	response := serveHealth(t, newTestHealthHandler(500, nil))
+
+	if response.Name != "yt-transcribe" || response.Status != HEALTH_STATUS_OK {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if len(response.Disk) != 1 || response.Disk[0].Free != 500 || response.Disk[0].Path != "/tmp" {
+		t.Fatalf("unexpected disk usage: %+v", response.Disk)
+	}
+}
+
+func TestHealthHandler_DegradedWhenLowOnSpace(t *testing.T) {
+	response := serveHealth(t, newTestHealthHandler(50, nil))
+
+	if response.Status != HEALTH_STATUS_DEGRADED {
+		t.Fatalf("expected status %q, got %q", HEALTH_STATUS_DEGRADED, response.Status)
+	}
+}
+
+func TestHealthHandler_DegradedWhenStatFails(t *testing.T) {
+	response := serveHealth(t, newTestHealthHandler(0, errors.New("statfs failed")))
+
+	if response.Status != HEALTH_STATUS_DEGRADED {
+		t.Fatalf("expected status %q, got %q", HEALTH_STATUS_DEGRADED, response.Status)
+	}
+}
+
+func TestHealthHandler_ReportsCookieStats(t *testing.T) {
+	stats := []downloader.CookieStats{{File: "a.txt", Successes: 3, Healthy: true}, {File: "b.txt", Failures: 1}}
+	handler := newTestHealthHandler(500, nil).WithCookieStats(func() []downloader.CookieStats { return stats })
+
+	response := serveHealth(t, handler)
+	if response.Status != HEALTH_STATUS_OK {
+		t.Fatalf("expected status %q, got %q", HEALTH_STATUS_OK, response.Status)
+	}
+	if len(response.Cookies) != 2 || response.Cookies[0].Successes != 3 || response.Cookies[1].Failures != 1 {
+		t.Fatalf("unexpected cookie stats: %+v", response.Cookies)
+	}
+
+	stats[0].Healthy = false
+	if response := serveHealth(t, handler); response.Status != HEALTH_STATUS_DEGRADED {
+		t.Fatalf("expected status %q when every cookie file cools down, got %q", HEALTH_STATUS_DEGRADED, response.Status)
+	}
+}
+
+func TestHealthHandler_ReportsToolVersions(t *testing.T) {
+	tools := []toolversion.Version{
+		{Name: "yt-dlp", Path: "yt-dlp", Version: "2025.01.15"},
+		{Name: "whisper-cli", Path: "/opt/whisper-cli", Error: "executable file not found"},
+	}
+
+	response := serveHealth(t, newTestHealthHandler(500, nil).WithToolVersions(tools))
+	if len(response.Tools) != 2 || response.Tools[0].Version != "2025.01.15" {
+		t.Fatalf("unexpected tool versions: %+v", response.Tools)
+	}
+	if response.Status != HEALTH_STATUS_DEGRADED {
+		t.Fatalf("expected status %q when a tool is missing, got %q", HEALTH_STATUS_DEGRADED, response.Status)
+	}
+}
+
+func TestHealthHandler_MethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	recorder := httptest.NewRecorder()
+
+	newTestHealthHandler(500, nil).ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status %d, got %d", http.StatusMethodNotAllowed, recorder.Code)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != http.MethodGet {
+		t.Fatalf("expected Allow header %q, got %q", http.MethodGet, allow)
+	}
+}
+
+func TestHealthHandler_UnknownPath(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/nope", nil)
+	recorder := httptest.NewRecorder()
+
+	newTestHealthHandler(500, nil).ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, recorder.Code)
+	}
+}
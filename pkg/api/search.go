@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"yt-transcribe/pkg/repository"
+)
+
+type transcriptSearcher interface {
+	Search(ctx context.Context, query string, opts repository.SearchOptions) (*repository.SearchPage, error)
+}
+
+// SearchHandler serves full-text search over the stored transcripts on GET /api/search.
+//
+// Query parameters: q (required, web search syntax), platform (comma-separated), category,
+// since and until (YYYY-MM-DD, where until includes the whole day, or RFC 3339), limit and offset.
+type SearchHandler struct {
+	searcher transcriptSearcher
+	link     DeepLinker
+}
+
+// DeepLinker returns the URL that opens a video at start, e.g. src.DeepLink. Handlers without one
+// link to the video itself.
+type DeepLinker func(platform, videoID, videoURL string, start time.Duration) string
+
+// at returns the URL that opens item at start.
+func (link DeepLinker) at(item repository.MediaItem, start time.Duration) string {
+	if link == nil {
+		return item.URL
+	}
+	return link(item.Platform, item.VideoID, item.URL, start)
+}
+
+type searchResponse struct {
+	Query  string `json:"query"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	// NextOffset is the offset of the next page, or nil on the last page.
+	NextOffset *int           `json:"nextOffset"`
+	Results    []searchResult `json:"results"`
+}
+
+// searchResult is a matching video. Snippets are HTML-escaped with the matched words in <mark>
+// tags; the hit Text is plain, unescaped text.
+type searchResult struct {
+	ID        string      `json:"id"`
+	URL       string      `json:"url"`
+	Platform  string      `json:"platform"`
+	VideoID   string      `json:"videoId"`
+	Title     string      `json:"title"`
+	Category  string      `json:"category,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Rank      float64     `json:"rank"`
+	Snippet   string      `json:"snippet"`
+	Hits      []searchHit `json:"hits"`
+}
+
+// searchHit is a matching segment; Start and End are in seconds from the start of the video.
+type searchHit struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Snippet string  `json:"snippet,omitempty"`
+	// URL opens the video at Start where the platform supports it.
+	URL string `json:"url"`
+}
+
+// NewSearchHandler returns a SearchHandler. A nil searcher makes every request fail with 503 Service
+// Unavailable, for servers without a database.
+func NewSearchHandler(searcher transcriptSearcher) *SearchHandler {
+	return &SearchHandler{searcher: searcher}
+}
+
+// WithDeepLinks makes hit URLs open the video at the hit with link.
+func (h *SearchHandler) WithDeepLinks(link DeepLinker) *SearchHandler {
+	h.link = link
+	return h
+}
+
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	if h.searcher == nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "search is not configured"})
+		return
+	}
+
+	params := r.URL.Query()
+	query := strings.TrimSpace(params.Get("q"))
+	if query == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "q is required"})
+		return
+	}
+
+	opts, err := searchOptionsFromQuery(params)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := h.searcher.Search(r.Context(), query, opts)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: fmt.Sprintf("search failed: %v", err)})
+		return
+	}
+
+	response := searchResponse{Query: query, Total: page.Total, Limit: opts.Limit, Offset: opts.Offset, Results: []searchResult{}}
+	if next := opts.Offset + len(page.Results); len(page.Results) == opts.Limit && next < page.Total {
+		response.NextOffset = &next
+	}
+	for _, result := range page.Results {
+		item := result.Item
+		converted := searchResult{
+			ID:        item.ID,
+			URL:       item.URL,
+			Platform:  item.Platform,
+			VideoID:   item.VideoID,
+			Title:     result.Title,
+			Category:  result.Category,
+			CreatedAt: item.CreatedAt,
+			Rank:      result.Rank,
+			Snippet:   result.Snippet,
+			Hits:      []searchHit{},
+		}
+		for _, hit := range result.Hits {
+			converted.Hits = append(converted.Hits, searchHit{
+				Start:   hit.Start.Seconds(),
+				End:     hit.End.Seconds(),
+				Text:    hit.Text,
+				Snippet: hit.Snippet,
+				URL:     h.link.at(item, hit.Start),
+			})
+		}
+		response.Results = append(response.Results, converted)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// searchOptionsFromQuery reads the filters and the page of a search request, applying the
+// repository defaults so the response can report them.
+func searchOptionsFromQuery(params url.Values) (repository.SearchOptions, error) {
+	opts := repository.SearchOptions{Category: strings.TrimSpace(params.Get("category")), Limit: repository.DEFAULT_SEARCH_LIMIT}
+	for _, platform := range strings.Split(params.Get("platform"), ",") {
+		if platform = strings.TrimSpace(platform); platform != "" {
+			opts.Platforms = append(opts.Platforms, platform)
+		}
+	}
+
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > repository.MAX_SEARCH_LIMIT {
+			return opts, fmt.Errorf("limit must be between 1 and %d, got %q", repository.MAX_SEARCH_LIMIT, value)
+		}
+		opts.Limit = limit
+	}
+	if value := params.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("offset must be a non-negative integer, got %q", value)
+		}
+		opts.Offset = offset
+	}
+
+	var err error
+	if value := params.Get("since"); value != "" {
+		if opts.CreatedFrom, err = repository.ParseDateBound(value, false); err != nil {
+			return opts, fmt.Errorf("since: %w", err)
+		}
+	}
+	if value := params.Get("until"); value != "" {
+		if opts.CreatedTo, err = repository.ParseDateBound(value, true); err != nil {
+			return opts, fmt.Errorf("until: %w", err)
+		}
+	}
+	if !opts.CreatedFrom.IsZero() && !opts.CreatedTo.IsZero() && !opts.CreatedFrom.Before(opts.CreatedTo) {
+		return opts, fmt.Errorf("since must be before until")
+	}
+	return opts, nil
+}
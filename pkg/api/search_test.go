@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yt-transcribe/pkg/repository"
+)
+
+type stubSearcher struct {
+	query string
+	opts  repository.SearchOptions
+	page  *repository.SearchPage
+	err   error
+}
+
+func (s *stubSearcher) Search(ctx context.Context, query string, opts repository.SearchOptions) (*repository.SearchPage, error) {
+	s.query, s.opts = query, opts
+	return s.page, s.err
+}
+
+// youtubeLinks stands in for src.DeepLink: it links YouTube videos at start and other videos to
+// their URL.
+func youtubeLinks(platform, videoID, videoURL string, start time.Duration) string {
+	if platform != "youtube" {
+		return videoURL
+	}
+	return fmt.Sprintf("https://www.youtube.com/watch?v=%s&t=%ds", videoID, int(start.Seconds()))
+}
+
+func TestSearchHandler_ReturnsHitsWithDeepLinks(t *testing.T) {
+	searcher := &stubSearcher{page: &repository.SearchPage{Total: 3, Results: []repository.SearchResult{{
+		Item:    repository.MediaItem{ID: "a", URL: "https://youtu.be/dQw4w9WgXcQ", Platform: "youtube", VideoID: "dQw4w9WgXcQ"},
+		Title:   "Go and Postgres",
+		Snippet: "using <mark>pgxpool</mark>",
+		Hits: []repository.SearchHit{{
+			Segment: repository.Segment{Start: 123500 * time.Millisecond, End: 126 * time.Second, Text: "the pgxpool config"},
+			Snippet: "the <mark>pgxpool</mark> config",
+		}},
+	}, {
+		Item: repository.MediaItem{ID: "b", URL: "https://www.instagram.com/reel/C1a2b3D4e5F/", Platform: "instagram", VideoID: "C1a2b3D4e5F"},
+		Hits: []repository.SearchHit{{Segment: repository.Segment{Start: 5 * time.Second}}},
+	}}}}
+	handler := NewSearchHandler(searcher).WithDeepLinks(youtubeLinks)
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=pgxpool&platform=youtube,instagram&category=Technology&since=2025-01-01&until=2025-01-31&limit=2", nil)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body)
+	}
+	if searcher.query != "pgxpool" || len(searcher.opts.Platforms) != 2 || searcher.opts.Category != "Technology" || searcher.opts.Limit != 2 {
+		t.Errorf("unexpected search %q %+v", searcher.query, searcher.opts)
+	}
+	if want := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC); !searcher.opts.CreatedTo.Equal(want) {
+		t.Errorf("want until to include the whole day, got %s", searcher.opts.CreatedTo)
+	}
+
+	var response searchResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Total != 3 || response.NextOffset == nil || *response.NextOffset != 2 || len(response.Results) != 2 {
+		t.Fatalf("unexpected page %+v", response)
+	}
+	hit := response.Results[0].Hits[0]
+	if hit.URL != "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=123s" || hit.Start != 123.5 || hit.Snippet != "the <mark>pgxpool</mark> config" {
+		t.Errorf("unexpected hit %+v", hit)
+	}
+	if url := response.Results[1].Hits[0].URL; url != "https://www.instagram.com/reel/C1a2b3D4e5F/" {
+		t.Errorf("want the video URL for platforms without deep links, got %s", url)
+	}
+}
+
+func TestSearchHandler_LastPageHasNoNextOffset(t *testing.T) {
+	searcher := &stubSearcher{page: &repository.SearchPage{Total: 1, Results: []repository.SearchResult{{Item: repository.MediaItem{ID: "a"}}}}}
+	recorder := httptest.NewRecorder()
+
+	NewSearchHandler(searcher).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/search?q=go&limit=1", nil))
+
+	var response searchResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.NextOffset != nil {
+		t.Errorf("expected no next page, got %d", *response.NextOffset)
+	}
+}
+
+func TestSearchHandler_RejectsInvalidRequests(t *testing.T) {
+	for _, target := range []string{
+		"/api/search",
+		"/api/search?q=+",
+		"/api/search?q=go&limit=0",
+		"/api/search?q=go&limit=1000",
+		"/api/search?q=go&offset=-1",
+		"/api/search?q=go&since=yesterday",
+		"/api/search?q=go&since=2025-02-01&until=2025-01-01",
+	} {
+		recorder := httptest.NewRecorder()
+		NewSearchHandler(&stubSearcher{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, recorder.Code)
+		}
+	}
+}
+
+func TestSearchHandler_Errors(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewSearchHandler(&stubSearcher{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/search?q=go", nil))
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != http.MethodGet {
+		t.Errorf("expected 405 with Allow GET, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	NewSearchHandler(nil).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/search?q=go", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d without a searcher, got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	NewSearchHandler(&stubSearcher{err: errors.New("connection refused")}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/search?q=go", nil))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+}
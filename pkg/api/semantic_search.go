@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"yt-transcribe/pkg/repository"
+)
+
+type passageSearcher interface {
+	Search(ctx context.Context, query string, limit int) ([]repository.PassageMatch, error)
+}
+
+// SemanticSearchHandler serves the transcript passages closest in meaning to a query on
+// GET /api/semantic-search. Query parameters: q (required) and limit.
+type SemanticSearchHandler struct {
+	searcher passageSearcher
+	link     DeepLinker
+}
+
+type semanticSearchResponse struct {
+	Query   string                 `json:"query"`
+	Results []semanticSearchResult `json:"results"`
+}
+
+type semanticSearchResult struct {
+	ID       string    `json:"id"`
+	URL      string    `json:"url"`
+	Platform string    `json:"platform"`
+	VideoID  string    `json:"videoId"`
+	Title    string    `json:"title"`
+	Distance float64   `json:"distance"`
+	Passage  searchHit `json:"passage"`
+}
+
+// NewSemanticSearchHandler returns a SemanticSearchHandler. A nil searcher makes every request
+// fail with 503 Service Unavailable, for servers without a database or embeddings API.
+func NewSemanticSearchHandler(searcher passageSearcher) *SemanticSearchHandler {
+	return &SemanticSearchHandler{searcher: searcher}
+}
+
+// WithDeepLinks makes passage URLs open the video at the passage with link.
+func (h *SemanticSearchHandler) WithDeepLinks(link DeepLinker) *SemanticSearchHandler {
+	h.link = link
+	return h
+}
+
+func (h *SemanticSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	if h.searcher == nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "semantic search is not configured"})
+		return
+	}
+
+	params := r.URL.Query()
+	query := strings.TrimSpace(params.Get("q"))
+	if query == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "q is required"})
+		return
+	}
+
+	limit := repository.DEFAULT_SEMANTIC_LIMIT
+	if value := params.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > repository.MAX_SEARCH_LIMIT {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("limit must be between 1 and %d, got %q", repository.MAX_SEARCH_LIMIT, value)})
+			return
+		}
+	}
+
+	matches, err := h.searcher.Search(r.Context(), query, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: fmt.Sprintf("semantic search failed: %v", err)})
+		return
+	}
+
+	response := semanticSearchResponse{Query: query, Results: []semanticSearchResult{}}
+	for _, match := range matches {
+		item, passage := match.Item, match.Passage
+		response.Results = append(response.Results, semanticSearchResult{
+			ID:       item.ID,
+			URL:      item.URL,
+			Platform: item.Platform,
+			VideoID:  item.VideoID,
+			Title:    match.Title,
+			Distance: match.Distance,
+			Passage: searchHit{
+				Start: passage.Start.Seconds(),
+				End:   passage.End.Seconds(),
+				Text:  passage.Text,
+				URL:   h.link.at(item, passage.Start),
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yt-transcribe/pkg/repository"
+)
+
+type stubPassageSearcher struct {
+	query   string
+	limit   int
+	matches []repository.PassageMatch
+	err     error
+}
+
+func (s *stubPassageSearcher) Search(ctx context.Context, query string, limit int) ([]repository.PassageMatch, error) {
+	s.query, s.limit = query, limit
+	return s.matches, s.err
+}
+
+func TestSemanticSearchHandler_ReturnsPassagesWithDeepLinks(t *testing.T) {
+	searcher := &stubPassageSearcher{matches: []repository.PassageMatch{{
+		Item:     repository.MediaItem{ID: "a", URL: "https://youtu.be/dQw4w9WgXcQ", Platform: "youtube", VideoID: "dQw4w9WgXcQ"},
+		Title:    "Go and Postgres",
+		Passage:  repository.Passage{Start: 61 * time.Second, End: 95 * time.Second, Text: "reuse connections across requests"},
+		Distance: 0.21,
+	}}}
+	recorder := httptest.NewRecorder()
+
+	NewSemanticSearchHandler(searcher).WithDeepLinks(youtubeLinks).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/semantic-search?q=connection+pooling&limit=3", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body)
+	}
+	if searcher.query != "connection pooling" || searcher.limit != 3 {
+		t.Errorf("unexpected search %q with limit %d", searcher.query, searcher.limit)
+	}
+	var response semanticSearchResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Results) != 1 {
+		t.Fatalf("want 1 result, got %+v", response)
+	}
+	if p := response.Results[0].Passage; p.Start != 61 || p.URL != "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=61s" {
+		t.Errorf("unexpected passage %+v", p)
+	}
+}
+
+func TestSemanticSearchHandler_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		handler  *SemanticSearchHandler
+		method   string
+		target   string
+		wantCode int
+	}{
+		{"wrong method", NewSemanticSearchHandler(&stubPassageSearcher{}), http.MethodPost, "/api/semantic-search?q=go", http.StatusMethodNotAllowed},
+		{"missing query", NewSemanticSearchHandler(&stubPassageSearcher{}), http.MethodGet, "/api/semantic-search", http.StatusBadRequest},
+		{"invalid limit", NewSemanticSearchHandler(&stubPassageSearcher{}), http.MethodGet, "/api/semantic-search?q=go&limit=x", http.StatusBadRequest},
+		{"not configured", NewSemanticSearchHandler(nil), http.MethodGet, "/api/semantic-search?q=go", http.StatusServiceUnavailable},
+		{"search fails", NewSemanticSearchHandler(&stubPassageSearcher{err: errors.New("503")}), http.MethodGet, "/api/semantic-search?q=go", http.StatusInternalServerError},
+	} {
+		recorder := httptest.NewRecorder()
+		tt.handler.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+		if recorder.Code != tt.wantCode {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.wantCode, recorder.Code)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,15 @@ import (
 	"strings"
 )
 
+// TEMP_DIR_PREFIX prefixes the temporary output directory created for each request.
+const TEMP_DIR_PREFIX = "yt-transcribe-api-"
+
+// rejection is implemented by errors reporting that a video was refused before it was downloaded,
+// such as src.RejectionError.
+type rejection interface {
+	Rejected() bool
+}
+
 type transcriptionExecutor interface {
 	Execute(ctx context.Context, videoURL, outputDir string) (blobURL string, err error)
 }
@@ -72,7 +82,7 @@ func (h *TranscribeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	outputDir, err := os.MkdirTemp(os.TempDir(), "yt-transcribe-api-")
+	outputDir, err := os.MkdirTemp(os.TempDir(), TEMP_DIR_PREFIX)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: fmt.Sprintf("failed to create temporary output directory: %v", err)})
 		return
@@ -80,6 +90,11 @@ func (h *TranscribeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer os.RemoveAll(outputDir)
 
 	blobURL, err := h.service.Execute(r.Context(), request.URL, outputDir)
+	var rejected rejection
+	if errors.As(err, &rejected) && rejected.Rejected() {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+		return
+	}
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: fmt.Sprintf("transcription failed: %v", err)})
 		return
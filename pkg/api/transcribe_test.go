@@ -11,6 +11,12 @@ import (
 	"testing"
 )
 
+// rejectionError is rejected like src.RejectionError.
+type rejectionError struct{ reason string }
+
+func (e *rejectionError) Error() string  { return "video rejected by pre-flight checks: " + e.reason }
+func (e *rejectionError) Rejected() bool { return true }
+
 type stubTranscriptionService struct {
 	executeFunc func(ctx context.Context, videoURL, outputDir string) (string, error)
 }
@@ -103,6 +109,30 @@ func TestTranscribeHandler_ExecuteFailure(t *testing.T) {
 	}
 }
 
+func TestTranscribeHandler_Rejected(t *testing.T) {
+	handler := NewTranscribeHandler(&stubTranscriptionService{
+		executeFunc: func(ctx context.Context, videoURL, outputDir string) (string, error) {
+			return "", &rejectionError{reason: "duration 12h0m0s exceeds the maximum of 3h0m0s"}
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/transcribe", strings.NewReader(`{"url":"https://example.com/watch?v=123"}`))
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d, got %d", http.StatusUnprocessableEntity, recorder.Code)
+	}
+
+	var response errorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("expected valid JSON response: %v", err)
+	}
+	if !strings.Contains(response.Error, "exceeds the maximum") {
+		t.Fatalf("expected rejection reason in error, got %q", response.Error)
+	}
+}
+
 func TestTranscribeHandler_Success(t *testing.T) {
 	var (
 		receivedURL       string
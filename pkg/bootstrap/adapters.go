@@ -0,0 +1,79 @@
+package bootstrap
+
+import (
+	"context"
+	"io"
+
+	"yt-transcribe/pkg/downloader"
+	"yt-transcribe/pkg/uploader"
+	"yt-transcribe/src"
+)
+
+// The packages under pkg do not import src; these adapters convert their types into the src ones.
+var (
+	_ src.VideoDownloader = videoDownloader{}
+	_ src.MetadataProber  = videoDownloader{}
+	_ src.Uploader        = storageUploader{}
+	_ src.StreamUploader  = storageUploader{}
+	_ src.ObjectStore     = objectStore{}
+)
+
+// videoDownloader adapts a downloader.Router to src.VideoDownloader and src.MetadataProber.
+type videoDownloader struct {
+	*downloader.Router
+}
+
+// Probe converts the metadata of the router into src.MediaMetadata.
+func (d videoDownloader) Probe(ctx context.Context, videoURL string) (*src.MediaMetadata, error) {
+	meta, err := d.Router.Probe(ctx, videoURL)
+	if err != nil {
+		return nil, err
+	}
+	return (*src.MediaMetadata)(meta), nil
+}
+
+// storageUploader adapts an uploader.Uploader to src.Uploader and src.StreamUploader.
+type storageUploader struct {
+	uploader uploader.Uploader
+}
+
+// Upload stores content as filename.
+func (u storageUploader) Upload(ctx context.Context, content string, filename string) (*src.UploadResult, error) {
+	result, err := u.uploader.Upload(ctx, content, filename)
+	if err != nil {
+		return nil, err
+	}
+	return (*src.UploadResult)(result), nil
+}
+
+// UploadReader stores the size bytes of r as filename.
+func (u storageUploader) UploadReader(ctx context.Context, r io.Reader, size int64, filename string) (*src.UploadResult, error) {
+	result, err := u.uploader.UploadReader(ctx, r, size, filename)
+	if err != nil {
+		return nil, err
+	}
+	return (*src.UploadResult)(result), nil
+}
+
+// objectStore adapts an uploader.ObjectStore to src.ObjectStore.
+type objectStore struct {
+	store uploader.ObjectStore
+}
+
+// List returns every object whose pathname starts with prefix.
+func (s objectStore) List(ctx context.Context, prefix string) ([]src.StoredObject, error) {
+	stored, err := s.store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]src.StoredObject, len(stored))
+	for i, obj := range stored {
+		objects[i] = src.StoredObject(obj)
+	}
+	return objects, nil
+}
+
+// Delete removes obj.
+func (s objectStore) Delete(ctx context.Context, obj src.StoredObject) error {
+	return s.store.Delete(ctx, uploader.StoredObject(obj))
+}
@@ -0,0 +1,244 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"yt-transcribe/pkg/downloader"
+	"yt-transcribe/pkg/openai"
+	"yt-transcribe/pkg/secrets"
+	"yt-transcribe/pkg/uploader"
+	"yt-transcribe/src"
+)
+
+// SplitList splits a comma-separated value, dropping blank entries.
+func SplitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envString returns the trimmed value of the environment variable name, or def when it is unset.
+func envString(name, def string) string {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt returns the integer value of the environment variable name, or def when it is unset.
+func envInt(name string, def int) (int, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", name, err)
+	}
+	return v, nil
+}
+
+// envFloat returns the float value of the environment variable name, or def when it is unset.
+func envFloat(name string, def float64) (float64, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", name, err)
+	}
+	return v, nil
+}
+
+// envDuration returns the duration value (e.g. "30s", "5m") of the environment variable name,
+// or def when it is unset.
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def, nil
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 30s or 5m: %w", name, err)
+	}
+	return v, nil
+}
+
+// loadRetryPolicy reads {prefix}_RETRY_MAX_ATTEMPTS, _BASE_DELAY, _MAX_DELAY and _JITTER,
+// falling back to src.DefaultRetryPolicy for unset values.
+func loadRetryPolicy(prefix string) (src.RetryPolicy, error) {
+	p := src.DefaultRetryPolicy()
+	var err error
+
+	if p.MaxAttempts, err = envInt(prefix+"_RETRY_MAX_ATTEMPTS", p.MaxAttempts); err != nil {
+		return p, err
+	}
+	if p.BaseDelay, err = envDuration(prefix+"_RETRY_BASE_DELAY", p.BaseDelay); err != nil {
+		return p, err
+	}
+	if p.MaxDelay, err = envDuration(prefix+"_RETRY_MAX_DELAY", p.MaxDelay); err != nil {
+		return p, err
+	}
+	if p.Jitter, err = envFloat(prefix+"_RETRY_JITTER", p.Jitter); err != nil {
+		return p, err
+	}
+	if err := p.Validate(); err != nil {
+		return p, fmt.Errorf("invalid %s retry policy: %w", prefix, err)
+	}
+	return p, nil
+}
+
+// loadRetryPolicies reads the retry policy of every pipeline stage.
+func loadRetryPolicies() (src.RetryPolicies, error) {
+	var (
+		policies src.RetryPolicies
+		err      error
+	)
+	if policies.Download, err = loadRetryPolicy("DOWNLOAD"); err != nil {
+		return policies, err
+	}
+	if policies.Transcribe, err = loadRetryPolicy("TRANSCRIBE"); err != nil {
+		return policies, err
+	}
+	if policies.Upload, err = loadRetryPolicy("UPLOAD"); err != nil {
+		return policies, err
+	}
+	return policies, nil
+}
+
+// loadLimits reads MAX_VIDEO_DURATION (e.g. "3h") and MAX_AUDIO_SIZE_MB. Unset or zero values disable the limit.
+func loadLimits() (src.Limits, error) {
+	var limits src.Limits
+
+	maxDuration, err := envDuration("MAX_VIDEO_DURATION", 0)
+	if err != nil {
+		return limits, err
+	}
+	maxSizeMB, err := envInt("MAX_AUDIO_SIZE_MB", 0)
+	if err != nil {
+		return limits, err
+	}
+	if maxDuration < 0 || maxSizeMB < 0 {
+		return limits, fmt.Errorf("MAX_VIDEO_DURATION and MAX_AUDIO_SIZE_MB must not be negative")
+	}
+
+	limits.MaxDuration = maxDuration
+	limits.MaxFileSize = int64(maxSizeMB) << 20
+	return limits, nil
+}
+
+// loadPipelineOptions reads the REPROCESS_* worker counts, queue size and in-flight cap of the
+// -reprocess-all pipeline, falling back to src.DefaultPipelineOptions for unset values.
+func loadPipelineOptions() (src.PipelineOptions, error) {
+	opts := src.DefaultPipelineOptions()
+	var err error
+
+	if opts.Downloaders, err = envInt("REPROCESS_DOWNLOADERS", opts.Downloaders); err != nil {
+		return opts, err
+	}
+	if opts.Transcribers, err = envInt("REPROCESS_TRANSCRIBERS", opts.Transcribers); err != nil {
+		return opts, err
+	}
+	if opts.Uploaders, err = envInt("REPROCESS_UPLOADERS", opts.Uploaders); err != nil {
+		return opts, err
+	}
+	if opts.QueueSize, err = envInt("REPROCESS_QUEUE_SIZE", opts.QueueSize); err != nil {
+		return opts, err
+	}
+	if opts.MaxInFlight, err = envInt("REPROCESS_MAX_IN_FLIGHT", opts.MaxInFlight); err != nil {
+		return opts, err
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, fmt.Errorf("REPROCESS_*: %w", err)
+	}
+	return opts, nil
+}
+
+// loadCookieFiles returns the cookie files listed in YT_DLP_COOKIES_FILES (comma-separated)
+// followed by the *.txt files in YT_DLP_COOKIES_DIR.
+func loadCookieFiles() ([]string, error) {
+	files := SplitList(os.Getenv("YT_DLP_COOKIES_FILES"))
+	if dir := strings.TrimSpace(os.Getenv("YT_DLP_COOKIES_DIR")); dir != "" {
+		dirFiles, err := downloader.CookieFilesInDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("YT_DLP_COOKIES_DIR: %w", err)
+		}
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// loadYTDLPThrottle reads YT_DLP_LIMIT_RATE, YT_DLP_SLEEP_INTERVAL, YT_DLP_MAX_SLEEP_INTERVAL,
+// YT_DLP_SLEEP_REQUESTS and YT_DLP_EXTRA_ARGS (whitespace-separated) into opts.
+func loadYTDLPThrottle(opts *downloader.YTDLPOptions) error {
+	var err error
+	opts.LimitRate = strings.TrimSpace(os.Getenv("YT_DLP_LIMIT_RATE"))
+	if opts.SleepInterval, err = envDuration("YT_DLP_SLEEP_INTERVAL", 0); err != nil {
+		return err
+	}
+	if opts.MaxSleepInterval, err = envDuration("YT_DLP_MAX_SLEEP_INTERVAL", 0); err != nil {
+		return err
+	}
+	if opts.SleepRequests, err = envDuration("YT_DLP_SLEEP_REQUESTS", 0); err != nil {
+		return err
+	}
+	opts.ExtraArgs = strings.Fields(os.Getenv("YT_DLP_EXTRA_ARGS"))
+	return nil
+}
+
+// loadS3Options reads the S3_* settings of the s3 storage backend. The access keys are secrets.
+func loadS3Options(ctx context.Context, projectID, environment string) (uploader.S3Options, error) {
+	opts := uploader.S3Options{
+		Endpoint:      os.Getenv("S3_ENDPOINT"),
+		Region:        envString("S3_REGION", uploader.DEFAULT_S3_REGION),
+		Bucket:        os.Getenv("S3_BUCKET"),
+		Prefix:        os.Getenv("S3_PREFIX"),
+		PublicURLBase: os.Getenv("S3_PUBLIC_URL_BASE"),
+		PathStyle:     os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+	}
+
+	var err error
+	if opts.AccessKeyID, err = secrets.GetSecret(ctx, "S3_ACCESS_KEY_ID", "S3_ACCESS_KEY_ID", projectID, environment); err != nil {
+		return opts, err
+	}
+	if opts.SecretAccessKey, err = secrets.GetSecret(ctx, "S3_SECRET_ACCESS_KEY", "S3_SECRET_ACCESS_KEY", projectID, environment); err != nil {
+		return opts, err
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	logSecretLoaded("S3_ACCESS_KEY_ID")
+	logSecretLoaded("S3_SECRET_ACCESS_KEY")
+	return opts, nil
+}
+
+// loadNotesPrompt reads the notes prompt template from NOTES_PROMPT_FILE, or returns nil for the
+// default prompt when it is not set.
+func loadNotesPrompt() (*template.Template, error) {
+	path := os.Getenv("NOTES_PROMPT_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NOTES_PROMPT_FILE: %w", err)
+	}
+	prompt, err := openai.ParseNotesPrompt(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("NOTES_PROMPT_FILE %s: %w", path, err)
+	}
+	log.Printf("Notes prompt: %s", path)
+	return prompt, nil
+}
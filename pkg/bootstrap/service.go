@@ -6,26 +6,93 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
+	"text/template"
+	"time"
 
 	"github.com/joho/godotenv"
+	"yt-transcribe/pkg/diskspace"
 	"yt-transcribe/pkg/downloader"
+	"yt-transcribe/pkg/openai"
+	"yt-transcribe/pkg/secrets"
+	"yt-transcribe/pkg/toolversion"
 	"yt-transcribe/pkg/transcriber"
 	"yt-transcribe/pkg/uploader"
-	"yt-transcribe/pkg/secrets"
 	"yt-transcribe/src"
 )
 
+// DEFAULT_MIN_FREE_DISK_MB is the free-space headroom used when MIN_FREE_DISK_MB is not set.
+const DEFAULT_MIN_FREE_DISK_MB = 512
+
+// Storage backends selectable with STORAGE_BACKEND.
+const (
+	STORAGE_BACKEND_VERCEL     = "vercel"
+	STORAGE_BACKEND_FILESYSTEM = "filesystem"
+	STORAGE_BACKEND_S3         = "s3"
+)
+
 var loadDotEnvOnce sync.Once
 
 // Config holds application configuration loaded from environment or Infisical.
 type Config struct {
-	WhisperModelPath        string
-	VercelBlobAPIURL        string
-	VercelBlobAPIToken      string
+	WhisperModelPath string
+	// YTDLPPath, FFmpegPath and WhisperCLIPath are the external tools, names looked up on PATH or file paths.
+	YTDLPPath      string
+	FFmpegPath     string
+	WhisperCLIPath string
+	// StorageBackend is one of the STORAGE_BACKEND_* constants.
+	StorageBackend string
+	// LocalStorageRoot and LocalStorageBaseURL configure the filesystem backend.
+	LocalStorageRoot    string
+	LocalStorageBaseURL string
+	// S3 configures the s3 backend.
+	S3 uploader.S3Options
+	// UploadGzip makes the s3 backend store gzip-compressed objects.
+	UploadGzip bool
+	// ArchiveAudio uploads the downloaded audio as Opus at ArchiveAudioBitrate next to each transcript.
+	ArchiveAudio        bool
+	ArchiveAudioBitrate string
+	VercelBlobAPIURL    string
+	VercelBlobAPIToken  string
+	// VercelBlobStoreURL and VercelBlobStoreToken give the gc mode access to the Vercel Blob list and
+	// delete API; the token is optional for everything else.
+	VercelBlobStoreURL      string
+	VercelBlobStoreToken    string
 	PostgresURL             string
 	YTDLPCookiesFile        string
 	YTDLPCookiesFromBrowser string
+	// YTDLPCookieFiles are rotated through by a downloader.CookiePool; they take precedence over YTDLPCookiesFile.
+	YTDLPCookieFiles    []string
+	YTDLPCookieCooldown time.Duration
+	// YTDLPProxies are rotated per job.
+	YTDLPProxies []string
+	// YTDLPThrottle holds the rate limit, sleep intervals and extra arguments passed to yt-dlp.
+	YTDLPThrottle downloader.YTDLPOptions
+	Retry         src.RetryPolicies
+	Limits        src.Limits
+	// Pipeline sizes the stages of -reprocess-all.
+	Pipeline src.PipelineOptions
+	// EmbeddingsURL is the base URL of an OpenAI-compatible API (e.g. http://localhost:8080/v1) used to
+	// embed transcript passages of about PassageChars characters with EmbeddingsModel. Empty disables
+	// semantic search.
+	EmbeddingsURL    string
+	EmbeddingsAPIKey string
+	EmbeddingsModel  string
+	PassageChars     int
+	// NotesURL is the base URL of an OpenAI-compatible API whose chat completions write Markdown notes
+	// on every transcript with NotesModel, sending at most NotesMaxChars characters per request.
+	// NotesPrompt replaces the default prompt when set. Empty NotesURL disables notes.
+	NotesURL      string
+	NotesAPIKey   string
+	NotesModel    string
+	NotesPrompt   *template.Template
+	NotesMaxChars int
+	// MinFreeSpace is the headroom in bytes each job keeps free on its working filesystems.
+	MinFreeSpace uint64
+
+	// whisperModelErr is why WhisperModelPath could not be loaded, reported by CheckTranscription.
+	whisperModelErr error
 }
 
 func loadDotEnv() {
@@ -52,32 +119,71 @@ func LoadConfigFromEnv(ctx context.Context) (*Config, error) {
 		log.Println("Infisical is disabled; reading from environment variables only")
 	}
 
-	whisperModelPath, err := secrets.GetSecret(ctx, "WHISPER_MODEL_PATH", "WHISPER_MODEL_PATH", infisicalProjectID, infisicalEnvironment)
-	if err != nil {
-		return nil, err
+	// Only the modes that transcribe need the model, so a missing one is reported by CheckTranscription
+	whisperModelPath, whisperModelErr := secrets.GetSecret(ctx, "WHISPER_MODEL_PATH", "WHISPER_MODEL_PATH", infisicalProjectID, infisicalEnvironment)
+	if whisperModelErr == nil && whisperModelPath == "" {
+		whisperModelErr = fmt.Errorf("WHISPER_MODEL_PATH not set")
 	}
-	if whisperModelPath == "" {
-		return nil, fmt.Errorf("WHISPER_MODEL_PATH not set")
+	if whisperModelErr == nil {
+		logSecretLoaded("WHISPER_MODEL_PATH")
+	} else {
+		log.Println("WHISPER_MODEL_PATH: not set (required to transcribe)")
 	}
-	logSecretLoaded("WHISPER_MODEL_PATH")
 
-	vercelBlobAPIURL, err := secrets.GetSecret(ctx, "VERCEL_BLOB_API_URL", "VERCEL_BLOB_API_URL", infisicalProjectID, infisicalEnvironment)
-	if err != nil {
-		return nil, err
-	}
-	if vercelBlobAPIURL == "" {
-		return nil, fmt.Errorf("VERCEL_BLOB_API_URL not set")
-	}
-	logSecretLoaded("VERCEL_BLOB_API_URL")
+	storageBackend := envString("STORAGE_BACKEND", STORAGE_BACKEND_VERCEL)
+	log.Printf("Storage backend: %s", storageBackend)
 
-	vercelBlobAPIToken, err := secrets.GetSecret(ctx, "VERCEL_BLOB_API_TOKEN", "VERCEL_BLOB_API_TOKEN", infisicalProjectID, infisicalEnvironment)
-	if err != nil {
-		return nil, err
+	var vercelBlobAPIURL, vercelBlobAPIToken, vercelBlobStoreToken, localStorageRoot, localStorageBaseURL string
+	var s3Options uploader.S3Options
+	var err error
+	switch storageBackend {
+	case STORAGE_BACKEND_VERCEL:
+		vercelBlobAPIURL, err = secrets.GetSecret(ctx, "VERCEL_BLOB_API_URL", "VERCEL_BLOB_API_URL", infisicalProjectID, infisicalEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		if vercelBlobAPIURL == "" {
+			return nil, fmt.Errorf("VERCEL_BLOB_API_URL not set")
+		}
+		logSecretLoaded("VERCEL_BLOB_API_URL")
+
+		vercelBlobAPIToken, err = secrets.GetSecret(ctx, "VERCEL_BLOB_API_TOKEN", "VERCEL_BLOB_API_TOKEN", infisicalProjectID, infisicalEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		if vercelBlobAPIToken == "" {
+			return nil, fmt.Errorf("VERCEL_BLOB_API_TOKEN not set")
+		}
+		logSecretLoaded("VERCEL_BLOB_API_TOKEN")
+
+		// Only needed to list and delete blobs (-gc)
+		vercelBlobStoreToken, _ = secrets.GetSecret(ctx, "BLOB_READ_WRITE_TOKEN", "BLOB_READ_WRITE_TOKEN", infisicalProjectID, infisicalEnvironment)
+		if vercelBlobStoreToken != "" {
+			logSecretLoaded("BLOB_READ_WRITE_TOKEN")
+		}
+	case STORAGE_BACKEND_FILESYSTEM:
+		localStorageRoot = os.Getenv("LOCAL_STORAGE_ROOT")
+		if localStorageRoot == "" {
+			return nil, fmt.Errorf("LOCAL_STORAGE_ROOT not set")
+		}
+		localStorageBaseURL = os.Getenv("LOCAL_STORAGE_BASE_URL")
+		log.Printf("Local storage root: %s", localStorageRoot)
+	case STORAGE_BACKEND_S3:
+		if s3Options, err = loadS3Options(ctx, infisicalProjectID, infisicalEnvironment); err != nil {
+			return nil, err
+		}
+		log.Printf("S3 bucket: %s (%s)", s3Options.Bucket, s3Options.Endpoint)
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND must be %q, %q or %q, got %q",
+			STORAGE_BACKEND_VERCEL, STORAGE_BACKEND_FILESYSTEM, STORAGE_BACKEND_S3, storageBackend)
 	}
-	if vercelBlobAPIToken == "" {
-		return nil, fmt.Errorf("VERCEL_BLOB_API_TOKEN not set")
+
+	// The Blob API is not known to accept compressed requests, so only S3 uploads are compressed
+	uploadGzip := os.Getenv("UPLOAD_GZIP") == "true"
+	if uploadGzip && storageBackend != STORAGE_BACKEND_S3 {
+		log.Printf("Warning: UPLOAD_GZIP only applies to the s3 backend and is ignored for %s", storageBackend)
+		uploadGzip = false
 	}
-	logSecretLoaded("VERCEL_BLOB_API_TOKEN")
 
 	// POSTGRES_URL is optional (only needed for -db mode)
 	postgresURL, err := secrets.GetSecret(ctx, "POSTGRES_URL", "POSTGRES_URL", infisicalProjectID, infisicalEnvironment)
@@ -91,6 +197,46 @@ func LoadConfigFromEnv(ctx context.Context) (*Config, error) {
 		log.Println("POSTGRES_URL: not set (optional)")
 	}
 
+	// Semantic search is optional; local embedding servers usually need no API key
+	embeddingsURL := envString("EMBEDDINGS_URL", "")
+	var embeddingsAPIKey string
+	if embeddingsURL != "" {
+		log.Printf("Embeddings API: %s", embeddingsURL)
+		embeddingsAPIKey, _ = secrets.GetSecret(ctx, "EMBEDDINGS_API_KEY", "EMBEDDINGS_API_KEY", infisicalProjectID, infisicalEnvironment)
+		if embeddingsAPIKey != "" {
+			logSecretLoaded("EMBEDDINGS_API_KEY")
+		}
+	}
+	passageChars, err := envInt("EMBEDDINGS_PASSAGE_CHARS", src.DEFAULT_PASSAGE_CHARS)
+	if err != nil {
+		return nil, err
+	}
+	if passageChars < 1 {
+		return nil, fmt.Errorf("EMBEDDINGS_PASSAGE_CHARS must be positive")
+	}
+
+	// Notes are optional; NOTES_PROMPT_FILE replaces the default prompt template
+	notesURL := envString("NOTES_URL", "")
+	var notesAPIKey string
+	var notesPrompt *template.Template
+	if notesURL != "" {
+		log.Printf("Notes API: %s", notesURL)
+		notesAPIKey, _ = secrets.GetSecret(ctx, "NOTES_API_KEY", "NOTES_API_KEY", infisicalProjectID, infisicalEnvironment)
+		if notesAPIKey != "" {
+			logSecretLoaded("NOTES_API_KEY")
+		}
+		if notesPrompt, err = loadNotesPrompt(); err != nil {
+			return nil, err
+		}
+	}
+	notesMaxChars, err := envInt("NOTES_MAX_CHARS", openai.DEFAULT_NOTES_MAX_CHARS)
+	if err != nil {
+		return nil, err
+	}
+	if notesMaxChars < 1 {
+		return nil, fmt.Errorf("NOTES_MAX_CHARS must be positive")
+	}
+
 	// yt-dlp cookie options are optional
 	ytdlpCookiesFile, _ := secrets.GetSecret(ctx, "YT_DLP_COOKIES_FILE", "YT_DLP_COOKIES_FILE", infisicalProjectID, infisicalEnvironment)
 	if ytdlpCookiesFile != "" {
@@ -102,16 +248,115 @@ func LoadConfigFromEnv(ctx context.Context) (*Config, error) {
 		logSecretLoaded("YT_DLP_COOKIES_FROM_BROWSER")
 	}
 
-	log.Println("=== Configuration Loaded Successfully ===")
+	ytdlpPath := envString("YT_DLP_PATH", downloader.YTDLP_BINARY)
+	ffmpegPath := envString("FFMPEG_PATH", downloader.FFMPEG_BINARY)
+	whisperCLIPath := envString("WHISPER_CLI_PATH", transcriber.WHISPER_CLI_BINARY)
+
+	cookieFiles, err := loadCookieFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(cookieFiles) > 0 {
+		log.Printf("yt-dlp cookie pool: %d files", len(cookieFiles))
+	}
+
+	cookieCooldown, err := envDuration("YT_DLP_COOKIE_COOLDOWN", downloader.DEFAULT_COOKIE_COOLDOWN)
+	if err != nil {
+		return nil, err
+	}
+
+	// Proxy URLs may embed credentials, so they are treated as a secret and never logged.
+	ytdlpProxies, _ := secrets.GetSecret(ctx, "YT_DLP_PROXIES", "YT_DLP_PROXIES", infisicalProjectID, infisicalEnvironment)
+	proxies := SplitList(ytdlpProxies)
+	if len(proxies) > 0 {
+		logSecretLoaded("YT_DLP_PROXIES")
+	}
+
+	var throttle downloader.YTDLPOptions
+	if err := loadYTDLPThrottle(&throttle); err != nil {
+		return nil, err
+	}
 
-	return &Config{
+	retry, err := loadRetryPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := loadLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := loadPipelineOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	minFreeDiskMB, err := envInt("MIN_FREE_DISK_MB", DEFAULT_MIN_FREE_DISK_MB)
+	if err != nil {
+		return nil, err
+	}
+	if minFreeDiskMB < 0 {
+		return nil, fmt.Errorf("MIN_FREE_DISK_MB must not be negative")
+	}
+
+	cfg := &Config{
 		WhisperModelPath:        whisperModelPath,
+		YTDLPPath:               ytdlpPath,
+		FFmpegPath:              ffmpegPath,
+		WhisperCLIPath:          whisperCLIPath,
+		StorageBackend:          storageBackend,
+		LocalStorageRoot:        localStorageRoot,
+		LocalStorageBaseURL:     localStorageBaseURL,
+		S3:                      s3Options,
+		UploadGzip:              uploadGzip,
+		ArchiveAudio:            os.Getenv("ARCHIVE_AUDIO") == "true",
+		ArchiveAudioBitrate:     envString("ARCHIVE_AUDIO_BITRATE", downloader.DEFAULT_OPUS_BITRATE),
 		VercelBlobAPIURL:        vercelBlobAPIURL,
 		VercelBlobAPIToken:      vercelBlobAPIToken,
+		VercelBlobStoreURL:      envString("VERCEL_BLOB_STORE_URL", uploader.DEFAULT_VERCEL_BLOB_STORE_URL),
+		VercelBlobStoreToken:    vercelBlobStoreToken,
 		PostgresURL:             postgresURL,
 		YTDLPCookiesFile:        ytdlpCookiesFile,
 		YTDLPCookiesFromBrowser: ytdlpCookiesFromBrowser,
-	}, nil
+		YTDLPCookieFiles:        cookieFiles,
+		YTDLPCookieCooldown:     cookieCooldown,
+		YTDLPProxies:            proxies,
+		YTDLPThrottle:           throttle,
+		Retry:                   retry,
+		Limits:                  limits,
+		Pipeline:                pipeline,
+		EmbeddingsURL:           embeddingsURL,
+		EmbeddingsAPIKey:        embeddingsAPIKey,
+		EmbeddingsModel:         envString("EMBEDDINGS_MODEL", openai.DEFAULT_EMBEDDINGS_MODEL),
+		PassageChars:            passageChars,
+		NotesURL:                notesURL,
+		NotesAPIKey:             notesAPIKey,
+		NotesModel:              envString("NOTES_MODEL", openai.DEFAULT_NOTES_MODEL),
+		NotesPrompt:             notesPrompt,
+		NotesMaxChars:           notesMaxChars,
+		MinFreeSpace:            uint64(minFreeDiskMB) << 20,
+		whisperModelErr:         whisperModelErr,
+	}
+	if err := cfg.ytdlpOptions(nil).Validate(); err != nil {
+		return nil, err
+	}
+
+	log.Println("=== Configuration Loaded Successfully ===")
+
+	return cfg, nil
+}
+
+// ytdlpOptions combines the yt-dlp settings of cfg with the given cookie pool, which may be nil.
+func (cfg *Config) ytdlpOptions(cookies *downloader.CookiePool) downloader.YTDLPOptions {
+	opts := cfg.YTDLPThrottle
+	opts.YTDLPPath = cfg.YTDLPPath
+	opts.FFmpegPath = cfg.FFmpegPath
+	opts.CookiesFile = cfg.YTDLPCookiesFile
+	opts.CookiesFromBrowser = cfg.YTDLPCookiesFromBrowser
+	opts.CookiePool = cookies
+	opts.Proxies = cfg.YTDLPProxies
+	return opts
 }
 
 // logSecretLoaded logs that a secret was successfully loaded (without revealing its value).
@@ -119,16 +364,137 @@ func logSecretLoaded(secretName string) {
 	log.Printf("%s: ✓ loaded", secretName)
 }
 
+// CheckTranscription reports whether cfg has what NewTranscriptionService needs. Modes that
+// only search or collect garbage load the configuration without it.
+func (cfg *Config) CheckTranscription() error {
+	return cfg.whisperModelErr
+}
+
+// NewTranscriptionServiceFromEnv loads the configuration and builds the transcription service from it.
 func NewTranscriptionServiceFromEnv() (src.TranscriptionService, error) {
 	ctx := context.Background()
 	cfg, err := LoadConfigFromEnv(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := cfg.CheckTranscription(); err != nil {
+		return nil, err
+	}
+
+	return NewTranscriptionService(cfg, NewRuntime(ctx, cfg)), nil
+}
+
+// Runtime holds the state shared by the transcription service and the health endpoint.
+type Runtime struct {
+	// Cookies is the yt-dlp cookie pool, or nil when no cookie files are configured.
+	Cookies *downloader.CookiePool
+	// Tools are the versions of the external tools detected at startup.
+	Tools []toolversion.Version
+}
+
+// NewRuntime creates the cookie pool described by cfg and detects the versions of the
+// external tools, logging each one.
+func NewRuntime(ctx context.Context, cfg *Config) *Runtime {
+	rt := &Runtime{Tools: DetectToolVersions(ctx, cfg)}
+	if len(cfg.YTDLPCookieFiles) > 0 {
+		rt.Cookies = downloader.NewCookiePool(cfg.YTDLPCookieFiles, cfg.YTDLPCookieCooldown)
+	}
+	return rt
+}
 
-	videoDownloader := downloader.NewYTDLPAudioDownloader(cfg.YTDLPCookiesFile, cfg.YTDLPCookiesFromBrowser)
+// DetectToolVersions runs the version command of yt-dlp, ffmpeg and whisper-cli and logs the results.
+func DetectToolVersions(ctx context.Context, cfg *Config) []toolversion.Version {
+	versions := toolversion.Detect(ctx,
+		toolversion.Tool{Name: "yt-dlp", Path: cfg.YTDLPPath, VersionArgs: []string{"--version"}},
+		toolversion.Tool{Name: "ffmpeg", Path: cfg.FFmpegPath, VersionArgs: []string{"-version"}},
+		toolversion.Tool{Name: "whisper-cli", Path: cfg.WhisperCLIPath, VersionArgs: []string{"--version"}},
+	)
+	for _, v := range versions {
+		if v.Error != "" {
+			log.Printf("Warning: could not detect %s version: %s", v.Name, v.Error)
+			continue
+		}
+		log.Printf("%s: %s (%s)", v.Name, v.Version, v.Path)
+	}
+	return versions
+}
+
+// NewTranscriptionService wires the downloader, transcriber and uploader described by cfg.
+// Pass the same Runtime to the health endpoint to report cookie statistics and tool versions.
+func NewTranscriptionService(cfg *Config, rt *Runtime) src.TranscriptionService {
+	// Plain media file URLs are fetched directly; everything else goes through yt-dlp.
+	httpClient := &http.Client{}
+	storage := newUploader(cfg, httpClient)
+	if fsUploader, ok := storage.(*uploader.FilesystemUploader); ok {
+		// Archived audio of the filesystem backend is addressed by file:// URLs
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.RegisterProtocol("file", fsUploader.FileTransport())
+		httpClient.Transport = transport
+	}
+	router := downloader.NewRouter(
+		downloader.NewHTTPMediaDownloader(httpClient, cfg.FFmpegPath),
+		downloader.NewYTDLPAudioDownloaderWithOptions(cfg.ytdlpOptions(rt.Cookies)),
+		httpClient,
+	)
 	audioTranscriber := transcriber.NewWhisperCPPTranscriber(cfg.WhisperModelPath)
-	blobUploader := uploader.NewVercelBlobUploader(cfg.VercelBlobAPIURL, cfg.VercelBlobAPIToken, &http.Client{})
+	audioTranscriber.BinaryPath = cfg.WhisperCLIPath
 
-	return src.NewTranscriptionService(videoDownloader, audioTranscriber, blobUploader), nil
+	service := &src.TranscriptionServiceImpl{
+		Downloader:   videoDownloader{router},
+		Transcriber:  audioTranscriber,
+		Uploader:     storageUploader{storage},
+		Retry:        cfg.Retry,
+		Limits:       cfg.Limits,
+		FreeSpace:    diskspace.Free,
+		MinFreeSpace: cfg.MinFreeSpace,
+		Model:        filepath.Base(cfg.WhisperModelPath),
+		ToolVersions: toolversion.Map(rt.Tools),
+	}
+	if cfg.ArchiveAudio {
+		service.AudioEncoder = downloader.NewOpusEncoder(cfg.FFmpegPath, cfg.ArchiveAudioBitrate)
+	}
+	if cfg.NotesURL != "" {
+		notesWriter := openai.NewNotesWriter(openai.NewClient(cfg.NotesURL, cfg.NotesAPIKey, &http.Client{}), cfg.NotesModel)
+		notesWriter.Prompt = cfg.NotesPrompt
+		notesWriter.MaxChars = cfg.NotesMaxChars
+		service.NotesGenerator = notesWriter
+	}
+	return service
+}
+
+// newUploader returns the uploader for the storage backend selected by cfg.
+func newUploader(cfg *Config, client *http.Client) uploader.Uploader {
+	switch cfg.StorageBackend {
+	case STORAGE_BACKEND_FILESYSTEM:
+		return uploader.NewFilesystemUploader(cfg.LocalStorageRoot, cfg.LocalStorageBaseURL)
+	case STORAGE_BACKEND_S3:
+		s3Uploader := uploader.NewS3Uploader(cfg.S3, client)
+		s3Uploader.Gzip = cfg.UploadGzip
+		return s3Uploader
+	}
+	return uploader.NewVercelBlobUploader(cfg.VercelBlobAPIURL, cfg.VercelBlobAPIToken, client)
+}
+
+// NewEmbedder returns the embedder configured by EMBEDDINGS_URL, or nil when semantic search is disabled.
+func NewEmbedder(cfg *Config) src.Embedder {
+	if cfg.EmbeddingsURL == "" {
+		return nil
+	}
+	return openai.NewEmbedder(openai.NewClient(cfg.EmbeddingsURL, cfg.EmbeddingsAPIKey, &http.Client{}), cfg.EmbeddingsModel)
+}
+
+// NewObjectStore returns the list and delete side of the storage backend selected by cfg.
+// The vercel backend needs BLOB_READ_WRITE_TOKEN for it.
+func NewObjectStore(cfg *Config) (src.ObjectStore, error) {
+	client := &http.Client{}
+	switch cfg.StorageBackend {
+	case STORAGE_BACKEND_FILESYSTEM:
+		return objectStore{uploader.NewFilesystemUploader(cfg.LocalStorageRoot, cfg.LocalStorageBaseURL)}, nil
+	case STORAGE_BACKEND_S3:
+		return objectStore{uploader.NewS3Uploader(cfg.S3, client)}, nil
+	}
+	if cfg.VercelBlobStoreToken == "" {
+		return nil, fmt.Errorf("BLOB_READ_WRITE_TOKEN not set (required to list blobs)")
+	}
+	return objectStore{uploader.NewVercelBlobStore(cfg.VercelBlobStoreURL, cfg.VercelBlobStoreToken, client)}, nil
 }
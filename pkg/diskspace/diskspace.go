@@ -0,0 +1,26 @@
+package diskspace
+
+// Usage describes the capacity of the filesystem holding Path.
+type Usage struct {
+	Path string `json:"path"`
+	// Free is the number of bytes available to unprivileged users.
+	Free  uint64 `json:"freeBytes"`
+	Total uint64 `json:"totalBytes"`
+}
+
+// statFunc is a variable that can be overridden for testing purposes.
+var statFunc = stat
+
+// Stat returns the Usage of the filesystem that contains path.
+func Stat(path string) (Usage, error) {
+	return statFunc(path)
+}
+
+// Free returns the number of bytes available on the filesystem that contains path.
+func Free(path string) (uint64, error) {
+	u, err := Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return u.Free, nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package diskspace
+
+import (
+	"errors"
+	"fmt"
+)
+
+// stat is a stub used on platforms without statfs(2).
+func stat(path string) (Usage, error) {
+	return Usage{}, fmt.Errorf("failed to stat filesystem of %s: %w", path, errors.ErrUnsupported)
+}
@@ -0,0 +1,42 @@
+package diskspace
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStat_ReportsTempDir(t *testing.T) {
+	dir := t.TempDir()
+
+	u, err := Stat(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.Path != dir {
+		t.Errorf("Path: want %q, got %q", dir, u.Path)
+	}
+	if u.Total == 0 {
+		t.Error("expected a non-zero total size")
+	}
+	if u.Free > u.Total {
+		t.Errorf("free space %d exceeds total %d", u.Free, u.Total)
+	}
+}
+
+func TestStat_MissingPath(t *testing.T) {
+	if _, err := Stat("/definitely/not/a/real/path"); err == nil {
+		t.Fatal("expected an error for a missing path, got nil")
+	}
+}
+
+func TestFree_PropagatesError(t *testing.T) {
+	old := statFunc
+	t.Cleanup(func() { statFunc = old })
+
+	expectedErr := errors.New("statfs failed")
+	statFunc = func(path string) (Usage, error) { return Usage{}, expectedErr }
+
+	if _, err := Free("/tmp"); !errors.Is(err, expectedErr) {
+		t.Errorf("want %v, got %v", expectedErr, err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package diskspace
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// stat uses statfs(2) to read the filesystem capacity.
+func stat(path string) (Usage, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return Usage{}, fmt.Errorf("failed to stat filesystem of %s: %w", path, err)
+	}
+	return Usage{
+		Path:  path,
+		Free:  uint64(fs.Bavail) * uint64(fs.Bsize),
+		Total: uint64(fs.Blocks) * uint64(fs.Bsize),
+	}, nil
+}
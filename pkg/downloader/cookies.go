@@ -0,0 +1,147 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DEFAULT_COOKIE_COOLDOWN is how long a cookie file flagged by a bot check is left unused.
+const DEFAULT_COOKIE_COOLDOWN = 6 * time.Hour
+
+// CookieStats reports the usage of one cookie file in a CookiePool.
+type CookieStats struct {
+	File           string     `json:"file"`
+	Successes      int        `json:"successes"`
+	Failures       int        `json:"failures"`
+	Healthy        bool       `json:"healthy"`
+	UnhealthyUntil *time.Time `json:"unhealthyUntil,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+}
+
+type cookieEntry struct {
+	file           string
+	successes      int
+	failures       int
+	unhealthyUntil time.Time
+	lastError      string
+}
+
+// CookiePool rotates yt-dlp invocations through several cookie files. A file whose download
+// fails a bot check ("Sign in to confirm you're not a bot") is cooled down before it is reused.
+// It is safe for concurrent use.
+type CookiePool struct {
+	mu       sync.Mutex
+	entries  []*cookieEntry
+	next     int
+	cooldown time.Duration
+	now      func() time.Time
+}
+
+// NewCookiePool creates a pool over files. A cooldown of zero uses DEFAULT_COOKIE_COOLDOWN.
+func NewCookiePool(files []string, cooldown time.Duration) *CookiePool {
+	if cooldown <= 0 {
+		cooldown = DEFAULT_COOKIE_COOLDOWN
+	}
+	entries := make([]*cookieEntry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, &cookieEntry{file: f})
+	}
+	return &CookiePool{entries: entries, cooldown: cooldown, now: time.Now}
+}
+
+// CookieFilesInDir returns the *.txt files in dir, sorted by name.
+func CookieFilesInDir(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cookie files in %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("failed to read cookie directory %s: %w", dir, err)
+		}
+		return nil, fmt.Errorf("no *.txt cookie files found in %s", dir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Len returns the number of cookie files in the pool.
+func (p *CookiePool) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.entries)
+}
+
+// Acquire returns the next healthy cookie file in round-robin order.
+// ok is false when every file is cooling down.
+func (p *CookiePool) Acquire() (file string, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for i := 0; i < len(p.entries); i++ {
+		e := p.entries[(p.next+i)%len(p.entries)]
+		if now.Before(e.unhealthyUntil) {
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.entries)
+		return e.file, true
+	}
+	return "", false
+}
+
+// Report records the outcome of an invocation that used file. Bot-check failures
+// put the file into cooldown; other failures are only counted.
+func (p *CookiePool) Report(file string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.entries {
+		if e.file != file {
+			continue
+		}
+		if err == nil {
+			e.successes++
+			return
+		}
+		e.failures++
+		e.lastError = err.Error()
+		if errors.Is(err, ErrBotCheck) {
+			e.unhealthyUntil = p.now().Add(p.cooldown)
+		}
+		return
+	}
+}
+
+// Stats returns per-file success and failure counts in pool order.
+func (p *CookiePool) Stats() []CookieStats {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	stats := make([]CookieStats, 0, len(p.entries))
+	for _, e := range p.entries {
+		s := CookieStats{
+			File:      e.file,
+			Successes: e.successes,
+			Failures:  e.failures,
+			Healthy:   !now.Before(e.unhealthyUntil),
+			LastError: e.lastError,
+		}
+		if !s.Healthy {
+			until := e.unhealthyUntil
+			s.UnhealthyUntil = &until
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
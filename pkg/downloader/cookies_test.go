@@ -0,0 +1,138 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCookiePool_RotatesRoundRobin tests that Acquire cycles through every file in order.
+func TestCookiePool_RotatesRoundRobin(t *testing.T) {
+	pool := NewCookiePool([]string{"a.txt", "b.txt", "c.txt"}, time.Hour)
+
+	var got []string
+	for range 4 {
+		file, ok := pool.Acquire()
+		if !ok {
+			t.Fatal("Acquire reported no healthy cookie file")
+		}
+		got = append(got, file)
+	}
+	if want := "a.txt b.txt c.txt a.txt"; strings.Join(got, " ") != want {
+		t.Errorf("want rotation %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+// TestCookiePool_BotCheckCoolsDown tests that a bot-check failure skips the file until its cooldown ends.
+func TestCookiePool_BotCheckCoolsDown(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	pool := NewCookiePool([]string{"a.txt", "b.txt"}, time.Hour)
+	pool.now = func() time.Time { return now }
+
+	pool.Report("a.txt", &DownloadError{Kind: ErrBotCheck, Op: "yt-dlp command failed", Err: errors.New("exit status 1")})
+	pool.Report("b.txt", &DownloadError{Kind: ErrNetwork, Op: "yt-dlp command failed", Err: errors.New("exit status 1")})
+
+	for range 2 {
+		if file, ok := pool.Acquire(); !ok || file != "b.txt" {
+			t.Fatalf("want b.txt while a.txt cools down, got %q (ok=%v)", file, ok)
+		}
+	}
+
+	stats := pool.Stats()
+	if stats[0].Healthy || stats[0].UnhealthyUntil == nil || !stats[0].UnhealthyUntil.Equal(now.Add(time.Hour)) {
+		t.Errorf("want a.txt unhealthy until %s, got %+v", now.Add(time.Hour), stats[0])
+	}
+	if !stats[1].Healthy || stats[1].Failures != 1 {
+		t.Errorf("want b.txt healthy with one failure, got %+v", stats[1])
+	}
+
+	now = now.Add(time.Hour)
+	if file, ok := pool.Acquire(); !ok || file != "a.txt" {
+		t.Errorf("want a.txt back after the cooldown, got %q (ok=%v)", file, ok)
+	}
+}
+
+// TestCookiePool_AllCoolingDown tests that Acquire fails when no file is healthy.
+func TestCookiePool_AllCoolingDown(t *testing.T) {
+	pool := NewCookiePool([]string{"a.txt"}, time.Hour)
+	pool.Report("a.txt", &DownloadError{Kind: ErrBotCheck, Err: errors.New("exit status 1")})
+
+	if file, ok := pool.Acquire(); ok {
+		t.Errorf("want no cookie file, got %q", file)
+	}
+}
+
+// TestCookieFilesInDir tests that only *.txt files are returned, sorted by name.
+func TestCookieFilesInDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.txt", "notes.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := CookieFilesInDir(dir)
+	if err != nil {
+		t.Fatalf("CookieFilesInDir failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("want %v, got %v", want, files)
+	}
+
+	if _, err := CookieFilesInDir(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without cookie files")
+	}
+}
+
+// TestDownloadAudio_CookiePool tests that downloads use pooled cookie files and report bot checks back to the pool.
+func TestDownloadAudio_CookiePool(t *testing.T) {
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	oldCmdCombinedOutput := cmdCombinedOutput
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+		cmdCombinedOutput = oldCmdCombinedOutput
+	})
+
+	osLookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+	var used []string
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		for i, arg := range args {
+			if arg == "--cookies" {
+				used = append(used, args[i+1])
+			}
+		}
+		return &exec.Cmd{Path: name, Args: append([]string{name}, args...)}
+	}
+	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("ERROR: [youtube] abc: Sign in to confirm you're not a bot."), errors.New("exit status 1")
+	}
+
+	pool := NewCookiePool([]string{"a.txt", "b.txt"}, time.Hour)
+	downloader := NewYTDLPAudioDownloaderWithOptions(YTDLPOptions{CookiesFile: "ignored.txt", CookiePool: pool})
+
+	for range 3 {
+		_, _, err := downloader.DownloadAudio(context.Background(), "https://youtube.com/watch?v=abc", t.TempDir())
+		if !errors.Is(err, ErrBotCheck) {
+			t.Fatalf("expected ErrBotCheck, got %v", err)
+		}
+	}
+
+	if want := "a.txt b.txt"; strings.Join(used, " ") != want {
+		t.Errorf("want cookie files %q to be used, got %q", want, strings.Join(used, " "))
+	}
+	for _, s := range downloader.CookieStats() {
+		if s.Healthy || s.Failures != 1 {
+			t.Errorf("want %s unhealthy with one failure, got %+v", s.File, s)
+		}
+	}
+}
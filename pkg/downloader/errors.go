@@ -0,0 +1,125 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors describing why a download failed. They are wrapped in a
+// *DownloadError, so callers can match them with errors.Is.
+var (
+	ErrVideoUnavailable = errors.New("video is unavailable or has been removed")
+	ErrPrivateVideo     = errors.New("video is private")
+	ErrGeoBlocked       = errors.New("video is not available in this region")
+	ErrAgeRestricted    = errors.New("video is age-restricted")
+	ErrMembersOnly      = errors.New("video is available to channel members only")
+	ErrUpcomingStream   = errors.New("live stream has not started yet")
+	ErrBotCheck         = errors.New("remote host requires sign-in to confirm this is not a bot")
+	ErrRateLimited      = errors.New("rate limited by remote host")
+	ErrNetwork          = errors.New("network error while downloading")
+	ErrNotMedia         = errors.New("URL does not point to an audio or video file")
+	ErrDownloadFailed   = errors.New("download failed")
+)
+
+// outputPattern maps a lower-cased fragment of yt-dlp output to the sentinel it indicates.
+type outputPattern struct {
+	fragment string
+	kind     error
+}
+
+// outputPatterns is checked in order, so more specific fragments must come first
+// (e.g. the bot check before the generic age "sign in to confirm" message).
+var outputPatterns = []outputPattern{
+	{"confirm you're not a bot", ErrBotCheck},
+	{"confirm you’re not a bot", ErrBotCheck},
+	{"private video", ErrPrivateVideo},
+	{"video is private", ErrPrivateVideo},
+	{"members-only", ErrMembersOnly},
+	{"join this channel to get access", ErrMembersOnly},
+	{"available to this channel's members", ErrMembersOnly},
+	{"confirm your age", ErrAgeRestricted},
+	{"age-restricted", ErrAgeRestricted},
+	{"inappropriate for some users", ErrAgeRestricted},
+	{"live event will begin", ErrUpcomingStream},
+	{"premieres in", ErrUpcomingStream},
+	{"not available in your country", ErrGeoBlocked},
+	{"not made this video available in your country", ErrGeoBlocked},
+	{"geo restriction", ErrGeoBlocked},
+	{"geo-restricted", ErrGeoBlocked},
+	{"http error 429", ErrRateLimited},
+	{"too many requests", ErrRateLimited},
+	{"video unavailable", ErrVideoUnavailable},
+	{"has been removed", ErrVideoUnavailable},
+	{"no longer available", ErrVideoUnavailable},
+	{"account associated with this video has been terminated", ErrVideoUnavailable},
+	{"http error 404", ErrVideoUnavailable},
+	{"http error 410", ErrVideoUnavailable},
+	{"unsupported url", ErrVideoUnavailable},
+	{"timed out", ErrNetwork},
+	{"connection reset", ErrNetwork},
+	{"connection refused", ErrNetwork},
+	{"temporary failure in name resolution", ErrNetwork},
+	{"network is unreachable", ErrNetwork},
+	{"incompleteread", ErrNetwork},
+	{"http error 500", ErrNetwork},
+	{"http error 502", ErrNetwork},
+	{"http error 503", ErrNetwork},
+	{"http error 504", ErrNetwork},
+	{"unable to download webpage", ErrNetwork},
+}
+
+// DownloadError is returned by YTDLPAudioDownloader when yt-dlp fails, and by HTTPMediaDownloader
+// when a request or ffmpeg fails.
+// Kind is one of the sentinel errors above; Err is the underlying process error.
+type DownloadError struct {
+	Kind   error
+	Op     string
+	Output string
+	Err    error
+}
+
+// Error implements the error interface, keeping the raw yt-dlp output for diagnostics.
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("%s: %v (%v)\nOutput: %s", e.Op, e.Kind, e.Err, e.Output)
+}
+
+// Unwrap exposes both the classification and the underlying error to errors.Is / errors.As.
+func (e *DownloadError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Retryable reports whether the same download may succeed if attempted again later.
+// Throttling, bot checks and network problems are transient. Failures caused by the video itself
+// (private, removed, geo-blocked, age-restricted, members-only, not yet aired, not a media file)
+// are permanent, and so are unclassified failures, such as an unsupported extractor.
+func (e *DownloadError) Retryable() bool {
+	switch e.Kind {
+	case ErrBotCheck, ErrRateLimited, ErrNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// classifyOutput inspects yt-dlp output and returns the sentinel error that best describes it.
+// ErrDownloadFailed is returned when no known pattern matches.
+func classifyOutput(output string) error {
+	lower := strings.ToLower(output)
+	for _, p := range outputPatterns {
+		if strings.Contains(lower, p.fragment) {
+			return p.kind
+		}
+	}
+	return ErrDownloadFailed
+}
+
+// newDownloadError classifies yt-dlp output and wraps it, together with the process error, in a *DownloadError.
+func newDownloadError(op string, output []byte, err error) *DownloadError {
+	return &DownloadError{
+		Kind:   classifyOutput(string(output)),
+		Op:     op,
+		Output: string(output),
+		Err:    err,
+	}
+}
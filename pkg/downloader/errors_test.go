@@ -0,0 +1,96 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyOutput(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   error
+	}{
+		{"private", "ERROR: [youtube] abc: Private video. Sign in if you've been granted access", ErrPrivateVideo},
+		{"removed", "ERROR: [youtube] abc: Video unavailable. This video has been removed by the uploader", ErrVideoUnavailable},
+		{"geo", "ERROR: [youtube] abc: The uploader has not made this video available in your country", ErrGeoBlocked},
+		{"age", "ERROR: [youtube] abc: Sign in to confirm your age. This video may be inappropriate for some users.", ErrAgeRestricted},
+		{"members", "ERROR: [youtube] abc: Join this channel to get access to members-only content like this video", ErrMembersOnly},
+		{"bot", "ERROR: [youtube] abc: Sign in to confirm you're not a bot. Use --cookies-from-browser", ErrBotCheck},
+		{"rate limit", "ERROR: unable to download video data: HTTP Error 429: Too Many Requests", ErrRateLimited},
+		{"network", "ERROR: [youtube] abc: Unable to download webpage: <urlopen error timed out>", ErrNetwork},
+		{"unknown", "something unexpected happened", ErrDownloadFailed},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := classifyOutput(tc.output); got != tc.want {
+				t.Errorf("classifyOutput(%q): want %v, got %v", tc.output, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDownloadError_Retryable(t *testing.T) {
+	permanent := []error{ErrVideoUnavailable, ErrPrivateVideo, ErrGeoBlocked, ErrAgeRestricted, ErrMembersOnly, ErrUpcomingStream, ErrNotMedia, ErrDownloadFailed}
+	for _, kind := range permanent {
+		if (&DownloadError{Kind: kind}).Retryable() {
+			t.Errorf("expected %v to be permanent", kind)
+		}
+	}
+
+	transient := []error{ErrBotCheck, ErrRateLimited, ErrNetwork}
+	for _, kind := range transient {
+		if !(&DownloadError{Kind: kind}).Retryable() {
+			t.Errorf("expected %v to be retryable", kind)
+		}
+	}
+}
+
+func TestDownloadError_Unwrap(t *testing.T) {
+	processErr := errors.New("exit status 1")
+	err := newDownloadError("yt-dlp command failed", []byte("ERROR: Private video"), processErr)
+
+	if !errors.Is(err, ErrPrivateVideo) {
+		t.Errorf("expected errors.Is(err, ErrPrivateVideo) to be true")
+	}
+	if !errors.Is(err, processErr) {
+		t.Errorf("expected errors.Is(err, processErr) to be true")
+	}
+
+	var downloadErr *DownloadError
+	if !errors.As(err, &downloadErr) {
+		t.Fatal("expected errors.As to find a *DownloadError")
+	}
+	if downloadErr.Output != "ERROR: Private video" {
+		t.Errorf("Output: want %q, got %q", "ERROR: Private video", downloadErr.Output)
+	}
+}
+
+func TestDownloadAudio_ClassifiesFailure(t *testing.T) {
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	oldCmdCombinedOutput := cmdCombinedOutput
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+		cmdCombinedOutput = oldCmdCombinedOutput
+	})
+
+	osLookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return &exec.Cmd{Path: name, Args: append([]string{name}, args...)}
+	}
+	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("ERROR: [youtube] test: Video unavailable"), errors.New("exit status 1")
+	}
+
+	_, _, err := NewYTDLPAudioDownloader("", "").DownloadAudio(context.Background(), "https://youtube.com/watch?v=test", t.TempDir())
+	if !errors.Is(err, ErrVideoUnavailable) {
+		t.Fatalf("expected ErrVideoUnavailable, got %v", err)
+	}
+}
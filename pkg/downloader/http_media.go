@@ -0,0 +1,341 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DEFAULT_HTTP_MAX_RESUMES is how often an interrupted HTTP download is resumed before giving up.
+const DEFAULT_HTTP_MAX_RESUMES = 5
+
+// FFPROBE_BINARY is the name of the ffprobe executable, looked up next to ffmpeg.
+const FFPROBE_BINARY = "ffprobe"
+
+// errFFmpegStopped is returned by copyResumable when ffmpeg exits before reading the whole stream.
+var errFFmpegStopped = errors.New("ffmpeg stopped reading the media stream")
+
+// mediaExtensions are URL path extensions that identify a direct media file.
+var mediaExtensions = []string{".mp3", ".m4a", ".mp4", ".aac", ".ogg", ".oga", ".opus", ".wav", ".flac", ".mov"}
+
+// HTTPMediaDownloader implements VideoDownloader for plain media file URLs (podcast CDNs, S3).
+// It fetches the file with HTTP, resuming interrupted transfers with Range requests, and pipes
+// it straight into ffmpeg, which converts it to the 16 kHz mono WAV whisper.cpp expects.
+// MP4 files must have their index at the start ("faststart"), because ffmpeg cannot seek in a pipe.
+type HTTPMediaDownloader struct {
+	client      *http.Client
+	ffmpegPath  string
+	ffprobePath string
+	maxResumes  int
+}
+
+// NewHTTPMediaDownloader creates an HTTPMediaDownloader. An empty ffmpegPath means FFMPEG_BINARY on PATH.
+func NewHTTPMediaDownloader(client *http.Client, ffmpegPath string) *HTTPMediaDownloader {
+	if ffmpegPath == "" {
+		ffmpegPath = FFMPEG_BINARY
+	}
+	return &HTTPMediaDownloader{
+		client:      client,
+		ffmpegPath:  ffmpegPath,
+		ffprobePath: ffprobeFor(ffmpegPath),
+		maxResumes:  DEFAULT_HTTP_MAX_RESUMES,
+	}
+}
+
+// ffprobeFor returns the ffprobe that belongs to ffmpegPath: on PATH when ffmpeg is looked up
+// there, otherwise in the same directory.
+func ffprobeFor(ffmpegPath string) string {
+	if !strings.ContainsRune(ffmpegPath, filepath.Separator) {
+		return FFPROBE_BINARY
+	}
+	return filepath.Join(filepath.Dir(ffmpegPath), FFPROBE_BINARY)
+}
+
+// DownloadAudio streams the media file at mediaURL through ffmpeg into a WAV file in outputDir.
+// The returned ID is derived from the file name and a hash of the URL, so different files
+// with the same name do not overwrite each other's transcripts.
+func (d *HTTPMediaDownloader) DownloadAudio(ctx context.Context, mediaURL string, outputDir string) (string, string, error) {
+	if _, err := osLookPath(d.ffmpegPath); err != nil {
+		return "", "", fmt.Errorf("ffmpeg not found (%s). Please install it or set FFMPEG_PATH: %w", d.ffmpegPath, err)
+	}
+
+	resp, err := d.get(ctx, mediaURL, 0, "")
+	if err != nil {
+		return "", "", err
+	}
+	if err := checkMediaType(resp, mediaURL); err != nil {
+		resp.Body.Close()
+		return "", "", err
+	}
+
+	id := mediaID(mediaURL)
+	outputPath := filepath.Join(outputDir, id+".wav")
+	cmd := commandExecutor(ctx, d.ffmpegPath,
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-vn", "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le",
+		"-y", outputPath,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		resp.Body.Close()
+		return "", "", fmt.Errorf("failed to open ffmpeg stdin: %w", err)
+	}
+	fmt.Printf("Executing command: %s\n", strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		resp.Body.Close()
+		return "", "", fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	copyErr := d.copyResumable(ctx, stdin, resp, mediaURL)
+	stdin.Close()
+	waitErr := cmd.Wait()
+
+	if copyErr != nil && !errors.Is(copyErr, errFFmpegStopped) {
+		return "", "", copyErr
+	}
+	if waitErr != nil || copyErr != nil {
+		return "", "", &DownloadError{Kind: ErrDownloadFailed, Op: "ffmpeg conversion failed", Output: stderr.String(), Err: errors.Join(waitErr, copyErr)}
+	}
+	if _, err := osStat(outputPath); err != nil {
+		return "", "", fmt.Errorf("ffmpeg reported success, but file not found at expected path: %s", outputPath)
+	}
+	return outputPath, id, nil
+}
+
+// Probe reports the file name and size of mediaURL from a HEAD request, and its duration from
+// ffprobe. When ffprobe fails the duration is left at zero, which means unknown.
+func (d *HTTPMediaDownloader) Probe(ctx context.Context, mediaURL string) (*MediaMetadata, error) {
+	meta := &MediaMetadata{ID: mediaID(mediaURL), Title: mediaFileName(mediaURL)}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, mediaURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid media URL: %w", err)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, &DownloadError{Kind: ErrNetwork, Op: "HEAD request failed", Err: err}
+	}
+	resp.Body.Close()
+
+	// Some servers only answer GET, and presigned S3/GCS URLs are signed for GET only, so they
+	// refuse HEAD with 403; the download itself will verify the file.
+	switch resp.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented, http.StatusUnauthorized, http.StatusForbidden:
+	default:
+		if err := statusError("HEAD request failed", resp); err != nil {
+			return nil, err
+		}
+		if err := checkMediaType(resp, mediaURL); err != nil {
+			return nil, err
+		}
+		if resp.ContentLength > 0 {
+			meta.EstimatedSize = resp.ContentLength
+		}
+	}
+
+	if meta.Duration, err = d.probeDuration(ctx, mediaURL); err != nil {
+		log.Printf("Warning: could not determine the duration of %s: %v", mediaURL, err)
+	}
+	return meta, nil
+}
+
+// probeDuration asks ffprobe for the duration of mediaURL. ffprobe reads only the headers of most
+// formats, so this does not download the file.
+func (d *HTTPMediaDownloader) probeDuration(ctx context.Context, mediaURL string) (time.Duration, error) {
+	cmd := commandExecutor(ctx, d.ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		mediaURL,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("ffprobe reported no duration: %q", strings.TrimSpace(string(output)))
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// get requests mediaURL from offset onwards. For offset > 0 it sends a Range request that only
+// succeeds while the file still matches validator (its ETag or Last-Modified value).
+func (d *HTTPMediaDownloader) get(ctx context.Context, mediaURL string, offset int64, validator string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid media URL: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, &DownloadError{Kind: ErrNetwork, Op: "GET request failed", Err: err}
+	}
+	if err := statusError("GET request failed", resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// copyResumable copies the body of resp to dst. When the transfer breaks off and the server
+// supports byte ranges, it requests the rest of the file and continues where it stopped.
+func (d *HTTPMediaDownloader) copyResumable(ctx context.Context, dst io.Writer, resp *http.Response, mediaURL string) error {
+	validator := resp.Header.Get("ETag")
+	if validator == "" {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	resumable := resp.Header.Get("Accept-Ranges") == "bytes"
+
+	w := &trackingWriter{w: dst}
+	for resumes := 0; ; resumes++ {
+		_, err := io.Copy(w, resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			return nil
+		}
+		if w.err != nil {
+			// ffmpeg stopped reading, e.g. because the data is not a format it understands.
+			return fmt.Errorf("%w: %v", errFFmpegStopped, w.err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !resumable || resumes >= d.maxResumes {
+			return &DownloadError{Kind: ErrNetwork, Op: fmt.Sprintf("download interrupted after %d bytes", w.n), Err: err}
+		}
+
+		log.Printf("Download of %s interrupted after %d bytes, resuming: %v", mediaURL, w.n, err)
+		resp, err = d.get(ctx, mediaURL, w.n, validator)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			// The file changed (If-Range mismatch) or the server ignored the range.
+			resp.Body.Close()
+			return &DownloadError{Kind: ErrNetwork, Op: "resume failed", Err: fmt.Errorf("expected status 206, got %d", resp.StatusCode)}
+		}
+	}
+}
+
+// trackingWriter counts the bytes written and keeps the first write error,
+// so copyResumable can tell a broken download from a failed consumer.
+type trackingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	t.n += int64(n)
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	return n, err
+}
+
+// statusError classifies an unsuccessful HTTP response, or returns nil for 2xx responses.
+func statusError(op string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	kind := ErrVideoUnavailable
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+		kind = ErrNetwork
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		kind = ErrPrivateVideo
+	}
+	return &DownloadError{Kind: kind, Op: op, Output: resp.Status, Err: fmt.Errorf("status code %d", resp.StatusCode)}
+}
+
+// checkMediaType rejects responses that are not audio or video, such as an HTML error page
+// served with status 200. Generic binary types are accepted when the URL has a media extension.
+func checkMediaType(resp *http.Response, mediaURL string) error {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	if isMediaType(mediaType) {
+		return nil
+	}
+	if (mediaType == "" || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream") && hasMediaExtension(mediaURL) {
+		return nil
+	}
+	return &DownloadError{Kind: ErrNotMedia, Op: "unexpected content type", Output: resp.Header.Get("Content-Type"), Err: fmt.Errorf("content type %q", mediaType)}
+}
+
+func isMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/") || mediaType == "application/ogg"
+}
+
+// hasMediaExtension reports whether the path of rawURL ends in one of mediaExtensions.
+func hasMediaExtension(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(mediaExtensions, strings.ToLower(path.Ext(u.Path)))
+}
+
+// mediaFileName returns the last path element of rawURL.
+func mediaFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+// mediaID returns a file-system safe ID for rawURL: the file name without extension,
+// followed by a short hash of the full URL.
+func mediaID(rawURL string) string {
+	name := strings.TrimSuffix(mediaFileName(rawURL), path.Ext(mediaFileName(rawURL)))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+	hash := hex.EncodeToString(sum[:4])
+	if name == "" || name == "_" {
+		return hash
+	}
+	return name + "-" + hash
+}
@@ -0,0 +1,261 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockFFmpeg makes commandExecutor run TestHelperFFmpeg, which copies its stdin to the output file.
+func mockFFmpeg(t *testing.T) {
+	t.Helper()
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+	})
+
+	osLookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cs := append([]string{"-test.run=TestHelperFFmpeg", "--"}, args...)
+		cmd := exec.CommandContext(ctx, os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_FFMPEG=1"}
+		return cmd
+	}
+}
+
+// TestHelperFFmpeg isn't a real test. It stands in for ffmpeg in the tests above.
+func TestHelperFFmpeg(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_FFMPEG") != "1" {
+		return
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		os.Exit(1)
+	}
+	if err := os.WriteFile(os.Args[len(os.Args)-1], data, 0644); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// TestHTTPMediaDownloader_Success tests that the file is streamed through ffmpeg into the output directory.
+func TestHTTPMediaDownloader_Success(t *testing.T) {
+	mockFFmpeg(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		io.WriteString(w, "episode audio")
+	}))
+	defer server.Close()
+
+	mediaURL := server.URL + "/feeds/Episode 42.mp3"
+	path, id, err := NewHTTPMediaDownloader(server.Client(), "").DownloadAudio(context.Background(), mediaURL, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadAudio failed: %v", err)
+	}
+	if !strings.HasPrefix(id, "Episode_42-") || id != mediaID(mediaURL) {
+		t.Errorf("unexpected ID %q", id)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "episode audio" {
+		t.Errorf("want converted file to contain the media stream, got %q", data)
+	}
+}
+
+// TestHTTPMediaDownloader_ResumesInterruptedDownload tests that a broken transfer continues with a Range request.
+func TestHTTPMediaDownloader_ResumesInterruptedDownload(t *testing.T) {
+	mockFFmpeg(t)
+	const body = "0123456789abcdefghij"
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mp4")
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("ETag", `"v1"`)
+
+		rangeHeader := r.Header.Get("Range")
+		ranges = append(ranges, rangeHeader)
+		if rangeHeader == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			io.WriteString(w, body[:8])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		if r.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("expected If-Range with the ETag, got %q", r.Header.Get("If-Range"))
+		}
+		offset, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, body[offset:])
+	}))
+	defer server.Close()
+
+	path, _, err := NewHTTPMediaDownloader(server.Client(), "").DownloadAudio(context.Background(), server.URL+"/a.m4a", t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadAudio failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != body {
+		t.Errorf("want %q, got %q", body, data)
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=8-" {
+		t.Errorf("expected one resume from byte 8, got ranges %q", ranges)
+	}
+}
+
+// TestHTTPMediaDownloader_RejectsNonMedia tests that an HTML page is a permanent failure.
+func TestHTTPMediaDownloader_RejectsNonMedia(t *testing.T) {
+	mockFFmpeg(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, "<html>Access denied</html>")
+	}))
+	defer server.Close()
+
+	_, _, err := NewHTTPMediaDownloader(server.Client(), "").DownloadAudio(context.Background(), server.URL+"/a.mp3", t.TempDir())
+	if !errors.Is(err, ErrNotMedia) {
+		t.Fatalf("expected ErrNotMedia, got %v", err)
+	}
+	var downloadErr *DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.Retryable() {
+		t.Errorf("expected %v to be permanent", err)
+	}
+}
+
+// TestHTTPMediaDownloader_ClassifiesStatus tests that HTTP errors map to the download sentinels.
+func TestHTTPMediaDownloader_ClassifiesStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrVideoUnavailable},
+		{http.StatusForbidden, ErrPrivateVideo},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusBadGateway, ErrNetwork},
+	}
+
+	mockFFmpeg(t)
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		_, _, err := NewHTTPMediaDownloader(server.Client(), "").DownloadAudio(context.Background(), server.URL+"/a.mp3", t.TempDir())
+		server.Close()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("status %d: want %v, got %v", tt.status, tt.want, err)
+		}
+	}
+}
+
+// mockFFprobe makes commandExecutor run TestHelperFFprobe, which prints output, and records the
+// probed executable and arguments.
+func mockFFprobe(t *testing.T, output string, calls *[][]string) {
+	t.Helper()
+	oldCommandExecutor := commandExecutor
+	t.Cleanup(func() { commandExecutor = oldCommandExecutor })
+
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		*calls = append(*calls, append([]string{name}, args...))
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestHelperFFprobe")
+		cmd.Env = []string{"GO_WANT_HELPER_FFPROBE=1", "FFPROBE_OUTPUT=" + output}
+		return cmd
+	}
+}
+
+// TestHelperFFprobe isn't a real test. It stands in for ffprobe in the tests above.
+func TestHelperFFprobe(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_FFPROBE") != "1" {
+		return
+	}
+	output := os.Getenv("FFPROBE_OUTPUT")
+	if output == "" {
+		io.WriteString(os.Stderr, "Invalid data found when processing input")
+		os.Exit(1)
+	}
+	io.WriteString(os.Stdout, output)
+	os.Exit(0)
+}
+
+// TestHTTPMediaDownloader_Probe tests that the HEAD response provides the estimated size and
+// ffprobe the duration.
+func TestHTTPMediaDownloader_Probe(t *testing.T) {
+	var calls [][]string
+	mockFFprobe(t, "3600.500000\n", &calls)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", "123456")
+	}))
+	defer server.Close()
+
+	meta, err := NewHTTPMediaDownloader(server.Client(), "/opt/ffmpeg/bin/ffmpeg").Probe(context.Background(), server.URL+"/show/ep1.mp3")
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if meta.EstimatedSize != 123456 || meta.Title != "ep1.mp3" || meta.Duration != 3600*time.Second+500*time.Millisecond {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if len(calls) != 1 || calls[0][0] != "/opt/ffmpeg/bin/ffprobe" || calls[0][len(calls[0])-1] != server.URL+"/show/ep1.mp3" {
+		t.Errorf("unexpected ffprobe calls %q", calls)
+	}
+}
+
+// TestHTTPMediaDownloader_ProbeWithoutDuration tests that an ffprobe failure leaves the duration unknown.
+func TestHTTPMediaDownloader_ProbeWithoutDuration(t *testing.T) {
+	var calls [][]string
+	mockFFprobe(t, "", &calls)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	meta, err := NewHTTPMediaDownloader(server.Client(), "").Probe(context.Background(), server.URL+"/ep2.mp3")
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if meta.Duration != 0 || len(calls) != 1 || calls[0][0] != FFPROBE_BINARY {
+		t.Errorf("unexpected metadata %+v after ffprobe calls %q", meta, calls)
+	}
+}
+
+// TestHTTPMediaDownloader_HeadForbidden tests that a URL refusing HEAD with 403, like a presigned
+// GET URL, is probed without a size and still downloaded.
+func TestHTTPMediaDownloader_HeadForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/ogg")
+		io.WriteString(w, "archived audio")
+	}))
+	defer server.Close()
+	mediaURL := server.URL + "/yt-transcribe/youtube/abc.opus?X-Amz-Signature=abc"
+	downloader := NewHTTPMediaDownloader(server.Client(), "")
+
+	var calls [][]string
+	mockFFprobe(t, "60\n", &calls)
+	meta, err := downloader.Probe(context.Background(), mediaURL)
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if meta.EstimatedSize != 0 || meta.Duration != time.Minute {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+
+	mockFFmpeg(t)
+	path, _, err := downloader.DownloadAudio(context.Background(), mediaURL, t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadAudio failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "archived audio" {
+		t.Errorf("want the GET response, got %q", data)
+	}
+}
@@ -0,0 +1,29 @@
+package downloader
+
+import (
+	"context"
+	"time"
+)
+
+// AudioDownloader downloads the audio of a video into outputDir. Every downloader in this package
+// implements it; it matches src.VideoDownloader.
+type AudioDownloader interface {
+	DownloadAudio(ctx context.Context, videoURL string, outputDir string) (filePath string, videoID string, err error)
+}
+
+// MediaMetadata describes a video before any media is fetched. It has the fields of
+// src.MediaMetadata, so callers can convert one into the other.
+type MediaMetadata struct {
+	ID       string
+	Title    string
+	Duration time.Duration
+	// EstimatedSize is the expected size in bytes of the audio stream that will be downloaded, or 0 if unknown.
+	EstimatedSize int64
+	IsLive        bool
+	IsUpcoming    bool
+}
+
+// metadataProber is implemented by the downloaders that can inspect a video without downloading it.
+type metadataProber interface {
+	Probe(ctx context.Context, videoURL string) (*MediaMetadata, error)
+}
@@ -0,0 +1,60 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DEFAULT_OPUS_BITRATE keeps speech intelligible for whisper at a fraction of the WAV size.
+const DEFAULT_OPUS_BITRATE = "32k"
+
+// OpusEncoder compresses downloaded audio to Opus with ffmpeg, for archival next to the transcript.
+type OpusEncoder struct {
+	ffmpegPath string
+	bitrate    string
+}
+
+// NewOpusEncoder creates an OpusEncoder. An empty ffmpegPath means FFMPEG_BINARY on PATH and an
+// empty bitrate means DEFAULT_OPUS_BITRATE.
+func NewOpusEncoder(ffmpegPath, bitrate string) *OpusEncoder {
+	if ffmpegPath == "" {
+		ffmpegPath = FFMPEG_BINARY
+	}
+	if bitrate == "" {
+		bitrate = DEFAULT_OPUS_BITRATE
+	}
+	return &OpusEncoder{ffmpegPath: ffmpegPath, bitrate: bitrate}
+}
+
+// Encode converts the audio file at inputPath to an Opus file at outputPath.
+func (e *OpusEncoder) Encode(ctx context.Context, inputPath, outputPath string) error {
+	if _, err := osLookPath(e.ffmpegPath); err != nil {
+		return fmt.Errorf("ffmpeg not found (%s). Please install it or set FFMPEG_PATH: %w", e.ffmpegPath, err)
+	}
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open audio: %w", err)
+	}
+	defer input.Close()
+
+	cmd := commandExecutor(ctx, e.ffmpegPath,
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-vn", "-c:a", "libopus", "-b:a", e.bitrate, "-application", "voip",
+		"-f", "opus", "-y", outputPath,
+	)
+	var stderr bytes.Buffer
+	cmd.Stdin = input
+	cmd.Stderr = &stderr
+	fmt.Printf("Executing command: %s\n", strings.Join(cmd.Args, " "))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg opus encoding failed: %w\nOutput: %s", err, stderr.String())
+	}
+	if _, err := osStat(outputPath); err != nil {
+		return fmt.Errorf("ffmpeg reported success, but file not found at expected path: %s", outputPath)
+	}
+	return nil
+}
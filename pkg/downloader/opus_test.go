@@ -0,0 +1,63 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestOpusEncoder_Encode tests that the input audio is piped through ffmpeg into the Opus file.
+func TestOpusEncoder_Encode(t *testing.T) {
+	mockFFmpeg(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "abc.wav")
+	if err := os.WriteFile(input, []byte("wav audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "abc.opus")
+	if err := NewOpusEncoder("", "").Encode(context.Background(), input, output); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "wav audio" {
+		t.Errorf("want encoded file to contain the input stream, got %q", data)
+	}
+}
+
+// TestOpusEncoder_Args tests the ffmpeg arguments, including the configured bitrate.
+func TestOpusEncoder_Args(t *testing.T) {
+	mockFFmpeg(t)
+	var gotArgs []string
+	next := commandExecutor
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		gotArgs = args
+		return next(ctx, name, args...)
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "abc.wav")
+	os.WriteFile(input, nil, 0644)
+
+	if err := NewOpusEncoder("", "24k").Encode(context.Background(), input, filepath.Join(dir, "abc.opus")); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	i := slices.Index(gotArgs, "-b:a")
+	if !slices.Contains(gotArgs, "libopus") || i < 0 || gotArgs[i+1] != "24k" {
+		t.Errorf("unexpected ffmpeg arguments %v", gotArgs)
+	}
+}
+
+// TestOpusEncoder_MissingFFmpeg tests that a missing ffmpeg binary is reported before anything runs.
+func TestOpusEncoder_MissingFFmpeg(t *testing.T) {
+	old := osLookPath
+	t.Cleanup(func() { osLookPath = old })
+	osLookPath = func(file string) (string, error) {
+		return "", errors.New("not found")
+	}
+	if err := NewOpusEncoder("", "").Encode(context.Background(), "in.wav", "out.opus"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
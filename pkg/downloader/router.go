@@ -0,0 +1,148 @@
+package downloader
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ROUTE_HEAD_TIMEOUT bounds the HEAD request Router uses to classify URLs it does not recognise.
+const ROUTE_HEAD_TIMEOUT = 10 * time.Second
+
+// routeCacheTTL bounds how long the route chosen by Probe is kept for reuse by DownloadAudio.
+const routeCacheTTL = 10 * time.Minute
+
+// cachedRoute is the downloader Probe chose for a URL, kept so DownloadAudio does not classify it again.
+type cachedRoute struct {
+	downloader AudioDownloader
+	probedAt   time.Time
+}
+
+// extractorHosts are sites that always need yt-dlp, so Router does not send them a HEAD request.
+var extractorHosts = []string{
+	"youtube.com", "youtu.be", "instagram.com", "tiktok.com", "twitter.com", "x.com",
+	"vimeo.com", "facebook.com", "twitch.tv", "soundcloud.com",
+}
+
+// Router is an AudioDownloader that sends direct media file URLs to an HTTPMediaDownloader and
+// everything else to a fallback downloader (yt-dlp). A URL is direct when its path has a media
+// extension, or when a HEAD request answers with an audio or video content type.
+type Router struct {
+	direct   *HTTPMediaDownloader
+	fallback AudioDownloader
+	client   *http.Client
+
+	// routes maps a video URL to the cachedRoute produced by Probe.
+	routes sync.Map
+}
+
+// NewRouter creates a Router. client is used for the HEAD requests that classify unknown URLs.
+func NewRouter(direct *HTTPMediaDownloader, fallback AudioDownloader, client *http.Client) *Router {
+	return &Router{direct: direct, fallback: fallback, client: client}
+}
+
+// DownloadAudio downloads videoURL with the downloader chosen for it, reusing the choice made
+// by Probe.
+func (r *Router) DownloadAudio(ctx context.Context, videoURL string, outputDir string) (string, string, error) {
+	downloader, ok := r.takeCachedRoute(videoURL)
+	if !ok {
+		downloader = r.route(ctx, videoURL)
+	}
+	return downloader.DownloadAudio(ctx, videoURL, outputDir)
+}
+
+// Probe probes videoURL with the downloader chosen for it. Downloaders that cannot probe
+// report empty metadata, which passes every limit.
+func (r *Router) Probe(ctx context.Context, videoURL string) (*MediaMetadata, error) {
+	downloader := r.route(ctx, videoURL)
+	r.cacheRoute(videoURL, downloader)
+	prober, ok := downloader.(metadataProber)
+	if !ok {
+		return &MediaMetadata{}, nil
+	}
+	return prober.Probe(ctx, videoURL)
+}
+
+// takeCachedRoute removes and returns the downloader cached by Probe for videoURL.
+// ok is false when there is no entry or it has expired.
+func (r *Router) takeCachedRoute(videoURL string) (downloader AudioDownloader, ok bool) {
+	value, found := r.routes.LoadAndDelete(videoURL)
+	if !found {
+		return nil, false
+	}
+	cached := value.(cachedRoute)
+	if time.Since(cached.probedAt) > routeCacheTTL {
+		return nil, false
+	}
+	return cached.downloader, true
+}
+
+// cacheRoute stores the downloader chosen for videoURL for DownloadAudio and evicts expired
+// entries (e.g. of URLs that were rejected by the pre-flight checks and never downloaded).
+func (r *Router) cacheRoute(videoURL string, downloader AudioDownloader) {
+	now := time.Now()
+	r.routes.Range(func(key, value any) bool {
+		if now.Sub(value.(cachedRoute).probedAt) > routeCacheTTL {
+			r.routes.Delete(key)
+		}
+		return true
+	})
+	r.routes.Store(videoURL, cachedRoute{downloader: downloader, probedAt: now})
+}
+
+// route returns the downloader for videoURL.
+func (r *Router) route(ctx context.Context, videoURL string) AudioDownloader {
+	u, err := url.Parse(videoURL)
+	if err == nil && u.Scheme == "file" {
+		// Artifacts of the filesystem storage backend, e.g. archived audio; the client must
+		// have a transport registered for them.
+		return r.direct
+	}
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return r.fallback
+	}
+	if hasMediaExtension(videoURL) {
+		return r.direct
+	}
+	if isExtractorHost(u.Hostname()) {
+		return r.fallback
+	}
+	if r.headIsMedia(ctx, videoURL) {
+		return r.direct
+	}
+	return r.fallback
+}
+
+// headIsMedia reports whether a HEAD request for videoURL answers with an audio or video content type.
+// Any error means no, leaving the URL to yt-dlp.
+func (r *Router) headIsMedia(ctx context.Context, videoURL string) bool {
+	ctx, cancel := context.WithTimeout(ctx, ROUTE_HEAD_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, videoURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && isMediaType(mediaType)
+}
+
+func isExtractorHost(host string) bool {
+	host = strings.ToLower(host)
+	return slices.ContainsFunc(extractorHosts, func(h string) bool {
+		return host == h || strings.HasSuffix(host, "."+h)
+	})
+}
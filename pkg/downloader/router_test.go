@@ -0,0 +1,73 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type stubDownloader struct{}
+
+func (stubDownloader) DownloadAudio(ctx context.Context, videoURL string, outputDir string) (string, string, error) {
+	return "", "", nil
+}
+
+func TestRouter_Route(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected a HEAD request, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/stream":
+			w.Header().Set("Content-Type", "audio/mpeg")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+		}
+	}))
+	defer server.Close()
+
+	direct := NewHTTPMediaDownloader(server.Client(), "")
+	fallback := stubDownloader{}
+	router := NewRouter(direct, fallback, server.Client())
+
+	tests := []struct {
+		url  string
+		want AudioDownloader
+	}{
+		{"https://cdn.example.com/podcast/ep1.MP3?token=abc", direct},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", fallback},
+		{"https://youtu.be/dQw4w9WgXcQ", fallback},
+		{server.URL + "/stream", direct},
+		{server.URL + "/watch/123", fallback},
+		{"file:///srv/transcripts/yt-transcribe/youtube/abc.opus", direct},
+		{"not a url", fallback},
+	}
+
+	for _, tt := range tests {
+		if got := router.route(context.Background(), tt.url); got != tt.want {
+			t.Errorf("%s: want %T, got %T", tt.url, tt.want, got)
+		}
+	}
+}
+
+// TestRouter_ReusesProbedRoute tests that DownloadAudio does not classify a URL Probe already routed.
+func TestRouter_ReusesProbedRoute(t *testing.T) {
+	var heads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heads++
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer server.Close()
+	router := NewRouter(NewHTTPMediaDownloader(server.Client(), ""), stubDownloader{}, server.Client())
+
+	if _, err := router.Probe(context.Background(), server.URL+"/watch/123"); err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if _, _, err := router.DownloadAudio(context.Background(), server.URL+"/watch/123", t.TempDir()); err != nil {
+		t.Fatalf("DownloadAudio failed: %v", err)
+	}
+	if heads != 1 {
+		t.Errorf("want 1 HEAD request, got %d", heads)
+	}
+}
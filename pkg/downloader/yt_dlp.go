@@ -1,12 +1,17 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // execCommandFunc is a type that allows us to mock exec.CommandContext in tests.
@@ -25,99 +30,406 @@ var cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
 	return cmd.CombinedOutput()
 }
 
+const (
+	// YTDLP_BINARY and FFMPEG_BINARY are the executables used when no path is configured.
+	YTDLP_BINARY  = "yt-dlp"
+	FFMPEG_BINARY = "ffmpeg"
+
+	// AUDIO_FORMAT selects the smallest audio-only stream of at least 48 kbps (plenty for
+	// 16 kHz speech recognition), falling back to any audio-only stream and then to any stream.
+	AUDIO_FORMAT = "ba[abr>=48]/ba/b"
+	// AUDIO_FORMAT_SORT makes yt-dlp prefer lower bitrates and smaller files when choosing a format.
+	AUDIO_FORMAT_SORT = "+abr,+size"
+	// AUDIO_POSTPROCESSOR_ARGS converts straight to the 16 kHz mono WAV whisper.cpp expects.
+	AUDIO_POSTPROCESSOR_ARGS = "ExtractAudio+ffmpeg_o:-ar 16000 -ac 1"
+	// DOWNLOAD_PRINT_TEMPLATE prints the video ID, metadata and final file path as one JSON line.
+	DOWNLOAD_PRINT_TEMPLATE = "after_move:%(.{id,title,duration,filepath})j"
+
+	// infoCacheTTL bounds how long a probed info JSON is kept for reuse by DownloadAudio.
+	infoCacheTTL = 10 * time.Minute
+)
+
+// cachedInfo is the raw info JSON of a probed video, kept so the download can skip re-extraction.
+// The media URLs in it are bound to the IP address that extracted them, so the download must
+// go through the same proxy as the probe.
+type cachedInfo struct {
+	data     []byte
+	proxy    string
+	probedAt time.Time
+}
+
 // YTDLPAudioDownloader is an implementation of VideoDownloader that uses the `yt-dlp` external tool.
 // It downloads the audio stream of a given video.
 type YTDLPAudioDownloader struct {
+	ytdlpPath          string
+	ffmpegPath         string
 	cookiesFile        string
 	cookiesFromBrowser string
+	cookiePool         *CookiePool
+	proxies            []string
+	throttleArgs       []string
+	extraArgs          []string
+
+	// nextProxy is the index into proxies of the proxy used by the next job.
+	nextProxy atomic.Uint64
+
+	// infoCache maps a video URL to the cachedInfo produced by Probe.
+	infoCache sync.Map
 }
 
 // NewYTDLPAudioDownloader creates and returns a new instance of YTDLPAudioDownloader.
 // This acts as a constructor, promoting consistency in object creation.
 func NewYTDLPAudioDownloader(cookiesFile, cookiesFromBrowser string) *YTDLPAudioDownloader {
-	return &YTDLPAudioDownloader{
-		cookiesFile:        cookiesFile,
-		cookiesFromBrowser: cookiesFromBrowser,
+	return NewYTDLPAudioDownloaderWithOptions(YTDLPOptions{
+		CookiesFile:        cookiesFile,
+		CookiesFromBrowser: cookiesFromBrowser,
+	})
+}
+
+// NewYTDLPAudioDownloaderWithOptions creates a YTDLPAudioDownloader from opts.
+// opts should have been checked with YTDLPOptions.Validate.
+func NewYTDLPAudioDownloaderWithOptions(opts YTDLPOptions) *YTDLPAudioDownloader {
+	d := &YTDLPAudioDownloader{
+		ytdlpPath:          opts.YTDLPPath,
+		ffmpegPath:         opts.FFmpegPath,
+		cookiesFile:        opts.CookiesFile,
+		cookiesFromBrowser: opts.CookiesFromBrowser,
+		cookiePool:         opts.CookiePool,
+		proxies:            opts.Proxies,
+		throttleArgs:       opts.throttleArgs(),
+		extraArgs:          opts.ExtraArgs,
 	}
+	if d.ytdlpPath == "" {
+		d.ytdlpPath = YTDLP_BINARY
+	}
+	if d.ffmpegPath == "" {
+		d.ffmpegPath = FFMPEG_BINARY
+	}
+	return d
+}
+
+// downloadResult is the JSON line printed by yt-dlp via DOWNLOAD_PRINT_TEMPLATE.
+type downloadResult struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Duration float64 `json:"duration"`
+	FilePath string  `json:"filepath"`
 }
 
 // DownloadAudio downloads the audio stream from the specified video URL
 // and saves it to the given output directory.
 // It returns the full path to the downloaded audio file and the video ID, or an error if the download fails.
+// yt-dlp failures are returned as a *DownloadError classified by one of the Err* sentinels.
+//
+// A single yt-dlp invocation selects the smallest adequate audio-only stream, converts it to
+// 16 kHz mono WAV and prints the video ID and final path. When Probe was called for the same URL
+// shortly before, its metadata is passed back with --load-info-json so the video is not extracted twice,
+// and the download goes through the same proxy as the probe.
 //
-// Dependencies: This function relies on the `yt-dlp` command-line tool being installed
-// and accessible in the system's PATH.
+// Dependencies: This function relies on the `yt-dlp` and `ffmpeg` command-line tools being
+// installed, either on the system's PATH or at the configured paths.
 //
 // Example yt-dlp command:
-// yt-dlp -x --audio-format wav --output "/path/to/output/videoID.wav" <video-url>
+// yt-dlp -f "ba[abr>=48]/ba/b" -S "+abr,+size" -x --audio-format wav --postprocessor-args "ExtractAudio+ffmpeg_o:-ar 16000 -ac 1"
+// --output "/path/to/output/%(id)s.%(ext)s" --no-simulate --print "after_move:%(.{id,title,duration,filepath})j" <video-url>
 func (d *YTDLPAudioDownloader) DownloadAudio(ctx context.Context, videoURL string, outputDir string) (string, string, error) {
 	// Check if ffmpeg is installed
-	if _, err := osLookPath("ffmpeg"); err != nil {
-		return "", "", fmt.Errorf("ffmpeg not found in PATH. ffmpeg is required by yt-dlp to process audio. Please install it to use this feature: %w", err)
+	if _, err := osLookPath(d.ffmpegPath); err != nil {
+		return "", "", fmt.Errorf("ffmpeg not found (%s). ffmpeg is required by yt-dlp to process audio. Please install it or set FFMPEG_PATH: %w", d.ffmpegPath, err)
 	}
 
 	// Check if yt-dlp is installed
-	if _, err := osLookPath("yt-dlp"); err != nil {
-		return "", "", fmt.Errorf("yt-dlp not found in PATH. Please install it to use this feature: %w", err)
+	if _, err := osLookPath(d.ytdlpPath); err != nil {
+		return "", "", fmt.Errorf("yt-dlp not found (%s). Please install it or set YT_DLP_PATH: %w", d.ytdlpPath, err)
 	}
 
-	// Build common arguments
-	commonArgs := []string{}
-	if d.cookiesFile != "" {
-		commonArgs = append(commonArgs, "--cookies", d.cookiesFile)
-	}
-	if d.cookiesFromBrowser != "" {
-		commonArgs = append(commonArgs, "--cookies-from-browser", d.cookiesFromBrowser)
+	info, cached := d.takeCachedInfo(videoURL)
+	proxy := info.proxy
+	if !cached {
+		proxy = d.rotateProxy()
 	}
 
-	// Get video ID
-	idArgs := append(commonArgs, "--get-id", videoURL)
-	idCmd := commandExecutor(ctx, "yt-dlp", idArgs...)
-	idOutput, err := cmdCombinedOutput(idCmd)
+	commonArgs, cookie, err := d.commonArgs(proxy)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get video ID: %v\nOutput: %s", err, string(idOutput))
-	}
-
-	lines := strings.Split(string(idOutput), "\n")
-	var videoID string
-	for i := len(lines) - 1; i >= 0; i-- {
-		trimmed := strings.TrimSpace(lines[i])
-		if trimmed != "" {
-			videoID = trimmed
-			break
-		}
-	}
-
-	if videoID == "" {
-		return "", "", fmt.Errorf("could not extract video ID from yt-dlp output: %s", string(idOutput))
+		return "", "", err
 	}
 
-	// Generate filename based on video ID
-	outputFilename := fmt.Sprintf("%s.wav", videoID)
-	downloadedFilePath := filepath.Join(outputDir, outputFilename)
-
 	downloadArgs := append(commonArgs,
+		"--format", AUDIO_FORMAT, // Smallest adequate audio-only stream
+		"--format-sort", AUDIO_FORMAT_SORT,
 		"-x",                    // Extract audio
 		"--audio-format", "wav", // Convert audio to wav format
-		"--output", downloadedFilePath, // Output path
+		"--postprocessor-args", AUDIO_POSTPROCESSOR_ARGS, // 16 kHz mono
+		"--output", filepath.Join(outputDir, "%(id)s.%(ext)s"), // Output path
 		"--restrict-filenames", // Keep filenames simple
-		videoURL,
+		"--no-simulate",        // --print implies --simulate otherwise
+		"--print", DOWNLOAD_PRINT_TEMPLATE,
+		"--no-progress",
 	)
-	cmd := commandExecutor(ctx, "yt-dlp", downloadArgs...)
 
-	fmt.Printf("Executing command: %s\n", cmd.String())
+	infoPath, ok := "", false
+	if cached {
+		infoPath, ok = writeInfoFile(outputDir, info.data)
+	}
+	if ok {
+		defer os.Remove(infoPath)
+		downloadArgs = append(downloadArgs, "--load-info-json", infoPath)
+	} else {
+		downloadArgs = append(downloadArgs, videoURL)
+	}
+	cmd := commandExecutor(ctx, d.ytdlpPath, downloadArgs...)
+
+	output, cmdErr := run(cmd, proxy)
+	path, id, err := parseDownloadOutput(output, cmdErr, outputDir)
+	d.reportCookie(cookie, err)
+	return path, id, err
+}
 
-	output, err := cmdCombinedOutput(cmd)
-	if err != nil {
-		if _, statErr := osStat(downloadedFilePath); os.IsNotExist(statErr) {
-			return "", "", fmt.Errorf("yt-dlp command failed: %v\nOutput: %s", err, string(output))
+// parseDownloadOutput extracts the downloaded file from the output of a DownloadAudio invocation.
+func parseDownloadOutput(output []byte, cmdErr error, outputDir string) (string, string, error) {
+	var result downloadResult
+	if err := json.Unmarshal(lastJSONLine(output), &result); err != nil || result.ID == "" {
+		if cmdErr != nil {
+			return "", "", newDownloadError("yt-dlp command failed", output, cmdErr)
 		}
+		return "", "", fmt.Errorf("could not extract video ID from yt-dlp output: %s", string(output))
+	}
+
+	downloadedFilePath := result.FilePath
+	if downloadedFilePath == "" {
+		downloadedFilePath = filepath.Join(outputDir, result.ID+".wav")
 	}
 
 	// Verify the file exists
 	if _, err := osStat(downloadedFilePath); os.IsNotExist(err) {
+		if cmdErr != nil {
+			return "", "", newDownloadError("yt-dlp command failed", output, cmdErr)
+		}
 		return "", "", fmt.Errorf("yt-dlp reported successful download, but file not found at expected path: %s", downloadedFilePath)
 	}
 
-	return downloadedFilePath, videoID, nil
+	return downloadedFilePath, result.ID, nil
+}
+
+// takeCachedInfo removes and returns the info cached by Probe for videoURL.
+// ok is false when there is no entry or it has expired.
+func (d *YTDLPAudioDownloader) takeCachedInfo(videoURL string) (info cachedInfo, ok bool) {
+	value, found := d.infoCache.LoadAndDelete(videoURL)
+	if !found {
+		return cachedInfo{}, false
+	}
+	info = value.(cachedInfo)
+	if time.Since(info.probedAt) > infoCacheTTL {
+		return cachedInfo{}, false
+	}
+	return info, true
+}
+
+// writeInfoFile writes a cached info JSON into outputDir for --load-info-json and returns its path.
+func writeInfoFile(outputDir string, data []byte) (path string, ok bool) {
+	f, err := os.CreateTemp(outputDir, "yt-dlp-*.info.json")
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", false
+	}
+	return f.Name(), true
+}
+
+// cacheInfo stores the info JSON of videoURL, and the proxy it was extracted through, for DownloadAudio
+// and evicts expired entries (e.g. of videos that were rejected by the pre-flight checks and never downloaded).
+func (d *YTDLPAudioDownloader) cacheInfo(videoURL string, data []byte, proxy string) {
+	now := time.Now()
+	d.infoCache.Range(func(key, value any) bool {
+		if now.Sub(value.(cachedInfo).probedAt) > infoCacheTTL {
+			d.infoCache.Delete(key)
+		}
+		return true
+	})
+	d.infoCache.Store(videoURL, cachedInfo{data: data, proxy: proxy, probedAt: now})
+}
+
+// rotateProxy returns the proxy for a new job, cycling through the configured proxies.
+func (d *YTDLPAudioDownloader) rotateProxy() string {
+	if len(d.proxies) == 0 {
+		return ""
+	}
+	n := d.nextProxy.Add(1) - 1
+	return d.proxies[n%uint64(len(d.proxies))]
+}
+
+// run executes a yt-dlp command. The command is logged and its output returned with the
+// proxy credentials redacted, so they never end up in logs or stored error messages.
+func run(cmd *exec.Cmd, proxy string) ([]byte, error) {
+	fmt.Printf("Executing command: %s\n", redactCommand(cmd.Args))
+
+	output, err := cmdCombinedOutput(cmd)
+	if proxy != "" {
+		output = bytes.ReplaceAll(output, []byte(proxy), []byte(redactProxy(proxy)))
+	}
+	return output, err
+}
+
+// commonArgs returns the yt-dlp arguments shared by every invocation: ffmpeg location, cookies, proxy, throttling
+// and extra arguments. When a CookiePool is configured, cookie is the file taken from it, which must
+// be passed to reportCookie once the invocation has finished.
+func (d *YTDLPAudioDownloader) commonArgs(proxy string) (args []string, cookie string, err error) {
+	args = []string{}
+	if d.ffmpegPath != FFMPEG_BINARY {
+		args = append(args, "--ffmpeg-location", d.ffmpegPath)
+	}
+	switch {
+	case d.cookiePool.Len() > 0:
+		file, ok := d.cookiePool.Acquire()
+		if !ok {
+			return nil, "", &DownloadError{
+				Kind: ErrBotCheck,
+				Op:   "no usable cookie file",
+				Err:  fmt.Errorf("all %d cookie files are cooling down", d.cookiePool.Len()),
+			}
+		}
+		cookie = file
+		args = append(args, "--cookies", file)
+	case d.cookiesFile != "":
+		args = append(args, "--cookies", d.cookiesFile)
+	}
+	if d.cookiesFromBrowser != "" {
+		args = append(args, "--cookies-from-browser", d.cookiesFromBrowser)
+	}
+	if proxy != "" {
+		args = append(args, "--proxy", proxy)
+	}
+	args = append(args, d.throttleArgs...)
+	args = append(args, d.extraArgs...)
+	return args, cookie, nil
+}
+
+// reportCookie records the outcome of an invocation that used a cookie file from the pool.
+func (d *YTDLPAudioDownloader) reportCookie(cookie string, err error) {
+	if cookie != "" {
+		d.cookiePool.Report(cookie, err)
+	}
+}
+
+// CookieStats returns the per-file usage of the configured CookiePool, or nil without one.
+func (d *YTDLPAudioDownloader) CookieStats() []CookieStats {
+	return d.cookiePool.Stats()
+}
+
+// ytdlpInfo is the subset of yt-dlp's --dump-single-json output used for pre-flight checks.
+type ytdlpInfo struct {
+	ID             string        `json:"id"`
+	Title          string        `json:"title"`
+	Duration       float64       `json:"duration"`
+	FileSizeApprox float64       `json:"filesize_approx"`
+	IsLive         bool          `json:"is_live"`
+	LiveStatus     string        `json:"live_status"`
+	Formats        []ytdlpFormat `json:"formats"`
+}
+
+// ytdlpFormat describes one of the streams yt-dlp can download for a video.
+type ytdlpFormat struct {
+	VCodec         string  `json:"vcodec"`
+	FileSize       float64 `json:"filesize"`
+	FileSizeApprox float64 `json:"filesize_approx"`
+	ABR            float64 `json:"abr"`
+}
+
+// Probe fetches the video metadata without downloading any media.
+// The service uses it to reject videos before downloading them.
+//
+// Example yt-dlp command:
+// yt-dlp --dump-single-json --skip-download --no-warnings <video-url>
+func (d *YTDLPAudioDownloader) Probe(ctx context.Context, videoURL string) (*MediaMetadata, error) {
+	if _, err := osLookPath(d.ytdlpPath); err != nil {
+		return nil, fmt.Errorf("yt-dlp not found (%s). Please install it or set YT_DLP_PATH: %w", d.ytdlpPath, err)
+	}
+
+	proxy := d.rotateProxy()
+	args, cookie, err := d.commonArgs(proxy)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "--dump-single-json", "--skip-download", "--no-warnings", videoURL)
+	cmd := commandExecutor(ctx, d.ytdlpPath, args...)
+	output, err := run(cmd, proxy)
+	if err != nil {
+		downloadErr := newDownloadError("failed to probe video metadata", output, err)
+		if downloadErr.Kind == ErrUpcomingStream {
+			// yt-dlp refuses to extract streams that have not started; report them as metadata
+			// so the caller's limits can reject them like any other live stream.
+			d.reportCookie(cookie, nil)
+			return &MediaMetadata{IsUpcoming: true}, nil
+		}
+		d.reportCookie(cookie, downloadErr)
+		return nil, downloadErr
+	}
+	d.reportCookie(cookie, nil)
+
+	data := lastJSONLine(output)
+	var info ytdlpInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("could not parse yt-dlp metadata: %w\nOutput: %s", err, string(output))
+	}
+	d.cacheInfo(videoURL, data, proxy)
+
+	return &MediaMetadata{
+		ID:            info.ID,
+		Title:         info.Title,
+		Duration:      time.Duration(info.Duration * float64(time.Second)),
+		EstimatedSize: info.estimatedAudioSize(),
+		IsLive:        info.IsLive || info.LiveStatus == "is_live",
+		IsUpcoming:    info.LiveStatus == "is_upcoming",
+	}, nil
+}
+
+// estimatedAudioSize returns the size in bytes of the stream DownloadAudio is expected to select
+// with AUDIO_FORMAT: the smallest audio-only stream of at least 48 kbps, otherwise the smallest
+// audio-only stream, otherwise the approximate size of the default format.
+func (i ytdlpInfo) estimatedAudioSize() int64 {
+	var adequate, fallback float64
+	for _, f := range i.Formats {
+		if f.VCodec != "none" {
+			continue
+		}
+		size := f.FileSize
+		if size == 0 {
+			size = f.FileSizeApprox
+		}
+		if size == 0 {
+			size = f.ABR * 1000 / 8 * i.Duration
+		}
+		if size == 0 {
+			continue
+		}
+		if f.ABR >= 48 && (adequate == 0 || size < adequate) {
+			adequate = size
+		}
+		if fallback == 0 || size < fallback {
+			fallback = size
+		}
+	}
+	switch {
+	case adequate > 0:
+		return int64(adequate)
+	case fallback > 0:
+		return int64(fallback)
+	default:
+		return int64(i.FileSizeApprox)
+	}
+}
+
+// lastJSONLine returns the last line of output that looks like a JSON object,
+// skipping any log lines yt-dlp printed before it.
+func lastJSONLine(output []byte) []byte {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if trimmed := strings.TrimSpace(lines[i]); strings.HasPrefix(trimmed, "{") {
+			return []byte(trimmed)
+		}
+	}
+	return output
 }
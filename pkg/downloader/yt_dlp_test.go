@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewYTDLPAudioDownloader ensures the constructor works correctly.
@@ -95,13 +96,13 @@ func TestDownloadAudio_Success(t *testing.T) {
 	}
 	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
 		if strings.Contains(cmd.String(), "--get-id") {
-			return []byte(expectedVideoID), nil
+			t.Errorf("expected a single yt-dlp invocation without --get-id, got: %s", cmd.String())
 		}
 		err := os.WriteFile(expectedFilePath, dummyFileContent, 0644)
 		if err != nil {
 			return nil, fmt.Errorf("mock failed to create dummy file: %w", err)
 		}
-		return []byte("yt-dlp success"), nil
+		return []byte(fmt.Sprintf("[ExtractAudio] Destination: %s\n{\"id\": %q, \"filepath\": %q}", expectedFilePath, expectedVideoID, expectedFilePath)), nil
 	}
 	osStat = func(name string) (os.FileInfo, error) {
 		return os.Stat(name)
@@ -188,7 +189,7 @@ func TestDownloadAudio_WithCookies(t *testing.T) {
 
 	cookiesFile := "cookies.txt"
 	cookiesFromBrowser := "chrome"
-	
+
 	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
 		argStr := strings.Join(args, " ")
 		if !strings.Contains(argStr, "--cookies "+cookiesFile) {
@@ -202,7 +203,7 @@ func TestDownloadAudio_WithCookies(t *testing.T) {
 			Args: append([]string{name}, args...),
 		}
 	}
-	
+
 	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
 		return []byte("test-video-id"), nil
 	}
@@ -212,3 +213,203 @@ func TestDownloadAudio_WithCookies(t *testing.T) {
 	// by making it fail after the first command if we wanted, but here we just want to see if commandExecutor is called correctly.
 	downloader.DownloadAudio(context.Background(), "https://youtube.com/watch?v=test", t.TempDir())
 }
+
+// TestProbe_ParsesMetadata tests that Probe maps yt-dlp JSON output to MediaMetadata.
+func TestProbe_ParsesMetadata(t *testing.T) {
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	oldCmdCombinedOutput := cmdCombinedOutput
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+		cmdCombinedOutput = oldCmdCombinedOutput
+	})
+
+	osLookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if !strings.Contains(strings.Join(args, " "), "--dump-single-json --skip-download") {
+			t.Errorf("expected a metadata-only invocation, got args: %v", args)
+		}
+		return &exec.Cmd{Path: name, Args: append([]string{name}, args...)}
+	}
+	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte(`[youtube] Extracting URL
+{"id":"abc","title":"Long stream","duration":43200,"live_status":"was_live","formats":[` +
+			`{"vcodec":"none","filesize":1000},{"vcodec":"none","abr":128},{"vcodec":"avc1","filesize":999999999}]}`), nil
+	}
+
+	meta, err := NewYTDLPAudioDownloader("", "").Probe(context.Background(), "https://youtube.com/watch?v=abc")
+	if err != nil {
+		t.Fatalf("Probe failed unexpectedly: %v", err)
+	}
+	if meta.ID != "abc" {
+		t.Errorf("ID: want %q, got %q", "abc", meta.ID)
+	}
+	if meta.Duration != 12*time.Hour {
+		t.Errorf("Duration: want %s, got %s", 12*time.Hour, meta.Duration)
+	}
+	// 128 kbps over 12 hours is larger than the 1000 byte stream, and video formats are ignored.
+	if want := int64(128 * 1000 / 8 * 43200); meta.EstimatedSize != want {
+		t.Errorf("EstimatedSize: want %d, got %d", want, meta.EstimatedSize)
+	}
+	if meta.IsLive || meta.IsUpcoming {
+		t.Errorf("expected a finished stream, got %+v", meta)
+	}
+}
+
+// TestProbe_UpcomingStream tests that yt-dlp's "will begin" error is reported as an upcoming stream.
+func TestProbe_UpcomingStream(t *testing.T) {
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	oldCmdCombinedOutput := cmdCombinedOutput
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+		cmdCombinedOutput = oldCmdCombinedOutput
+	})
+
+	osLookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return &exec.Cmd{Path: name, Args: append([]string{name}, args...)}
+	}
+	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("ERROR: [youtube] abc: This live event will begin in 3 hours."), errors.New("exit status 1")
+	}
+
+	meta, err := NewYTDLPAudioDownloader("", "").Probe(context.Background(), "https://youtube.com/watch?v=abc")
+	if err != nil {
+		t.Fatalf("Probe failed unexpectedly: %v", err)
+	}
+	if !meta.IsUpcoming {
+		t.Errorf("expected IsUpcoming to be true, got %+v", meta)
+	}
+}
+
+// TestDownloadAudio_SelectsSmallAudioAt16kHz tests that the download requests a small audio-only
+// stream converted to 16 kHz mono.
+func TestDownloadAudio_SelectsSmallAudioAt16kHz(t *testing.T) {
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	oldCmdCombinedOutput := cmdCombinedOutput
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+		cmdCombinedOutput = oldCmdCombinedOutput
+	})
+
+	osLookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+
+	var calls int
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		calls++
+		argStr := strings.Join(args, " ")
+		for _, want := range []string{
+			"--format " + AUDIO_FORMAT,
+			"--format-sort " + AUDIO_FORMAT_SORT,
+			"--postprocessor-args " + AUDIO_POSTPROCESSOR_ARGS,
+			"--print " + DOWNLOAD_PRINT_TEMPLATE,
+		} {
+			if !strings.Contains(argStr, want) {
+				t.Errorf("expected %q in command, args: %v", want, args)
+			}
+		}
+		return &exec.Cmd{Path: name, Args: append([]string{name}, args...)}
+	}
+	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("ERROR: Video unavailable"), errors.New("exit status 1")
+	}
+
+	NewYTDLPAudioDownloader("", "").DownloadAudio(context.Background(), "https://youtube.com/watch?v=test", t.TempDir())
+	if calls != 1 {
+		t.Errorf("expected exactly one yt-dlp invocation, got %d", calls)
+	}
+}
+
+// TestDownloadAudio_ReusesProbedInfo tests that a download following Probe loads the probed
+// metadata instead of extracting the video again.
+func TestDownloadAudio_ReusesProbedInfo(t *testing.T) {
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	oldCmdCombinedOutput := cmdCombinedOutput
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+		cmdCombinedOutput = oldCmdCombinedOutput
+	})
+
+	osLookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+
+	videoURL := "https://youtube.com/watch?v=abc"
+	var downloadArgs []string
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return &exec.Cmd{Path: name, Args: append([]string{name}, args...)}
+	}
+	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
+		if strings.Contains(cmd.String(), "--dump-single-json") {
+			return []byte(`{"id":"abc","duration":60}`), nil
+		}
+		downloadArgs = cmd.Args
+		return []byte("ERROR: Video unavailable"), errors.New("exit status 1")
+	}
+
+	downloader := NewYTDLPAudioDownloader("", "")
+	if _, err := downloader.Probe(context.Background(), videoURL); err != nil {
+		t.Fatalf("Probe failed unexpectedly: %v", err)
+	}
+	downloader.DownloadAudio(context.Background(), videoURL, t.TempDir())
+
+	argStr := strings.Join(downloadArgs, " ")
+	if !strings.Contains(argStr, "--load-info-json") {
+		t.Errorf("expected --load-info-json in download command, args: %v", downloadArgs)
+	}
+	if strings.Contains(argStr, videoURL) {
+		t.Errorf("expected the URL not to be extracted again, args: %v", downloadArgs)
+	}
+}
+
+// TestDownloadAudio_ConfiguredBinaryPaths tests that configured yt-dlp and ffmpeg paths are used.
+func TestDownloadAudio_ConfiguredBinaryPaths(t *testing.T) {
+	oldCommandExecutor := commandExecutor
+	oldOsLookPath := osLookPath
+	oldCmdCombinedOutput := cmdCombinedOutput
+	t.Cleanup(func() {
+		commandExecutor = oldCommandExecutor
+		osLookPath = oldOsLookPath
+		cmdCombinedOutput = oldCmdCombinedOutput
+	})
+
+	var looked []string
+	osLookPath = func(file string) (string, error) {
+		looked = append(looked, file)
+		return file, nil
+	}
+	var ran string
+	commandExecutor = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		ran = name
+		if !strings.Contains(strings.Join(args, " "), "--ffmpeg-location /opt/ffmpeg/bin/ffmpeg") {
+			t.Errorf("expected --ffmpeg-location in command, args: %v", args)
+		}
+		return &exec.Cmd{Path: name, Args: append([]string{name}, args...)}
+	}
+	cmdCombinedOutput = func(cmd *exec.Cmd) ([]byte, error) {
+		return []byte("ERROR: Video unavailable"), errors.New("exit status 1")
+	}
+
+	downloader := NewYTDLPAudioDownloaderWithOptions(YTDLPOptions{YTDLPPath: "/opt/yt-dlp", FFmpegPath: "/opt/ffmpeg/bin/ffmpeg"})
+	downloader.DownloadAudio(context.Background(), "https://youtube.com/watch?v=test", t.TempDir())
+
+	if strings.Join(looked, " ") != "/opt/ffmpeg/bin/ffmpeg /opt/yt-dlp" {
+		t.Errorf("expected configured paths to be looked up, got %v", looked)
+	}
+	if ran != "/opt/yt-dlp" {
+		t.Errorf("expected /opt/yt-dlp to be run, got %q", ran)
+	}
+}
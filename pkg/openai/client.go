@@ -0,0 +1,166 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client calls an OpenAI-compatible API, e.g. api.openai.com or a local llama.cpp server.
+type Client struct {
+	// baseURL is the API root including the version, e.g. https://api.openai.com/v1.
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a Client for the API at baseURL. apiKey may be empty for local servers and
+// client nil for http.DefaultClient.
+func NewClient(baseURL, apiKey string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: client,
+	}
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embeddings calls POST {baseURL}/embeddings and returns one vector per input, in input order.
+func (c *Client) Embeddings(ctx context.Context, model string, input []string) ([][]float32, error) {
+	var response embeddingsResponse
+	if err := c.post(ctx, "/embeddings", embeddingsRequest{Model: model, Input: input}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Data) != len(input) {
+		return nil, responseError(fmt.Errorf("want %d embeddings, got %d", len(input), len(response.Data)))
+	}
+	vectors := make([][]float32, len(input))
+	for _, d := range response.Data {
+		if d.Index < 0 || d.Index >= len(vectors) || vectors[d.Index] != nil {
+			return nil, responseError(fmt.Errorf("unexpected embedding index %d", d.Index))
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// ChatMessage is one message of a chat completion conversation.
+type ChatMessage struct {
+	// Role is "system", "user" or "assistant".
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message ChatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// ChatCompletion calls POST {baseURL}/chat/completions and returns the content of the first choice.
+func (c *Client) ChatCompletion(ctx context.Context, model string, messages []ChatMessage) (string, error) {
+	var response chatCompletionResponse
+	if err := c.post(ctx, "/chat/completions", chatCompletionRequest{Model: model, Messages: messages}, &response); err != nil {
+		return "", err
+	}
+	if len(response.Choices) == 0 {
+		return "", responseError(errors.New("no choices in chat completion"))
+	}
+	return response.Choices[0].Message.Content, nil
+}
+
+// post sends payload as JSON to path and decodes the JSON response into out.
+func (c *Client) post(ctx context.Context, path string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &APIError{Err: err, retryable: !errors.Is(err, context.Canceled)}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &APIError{StatusCode: resp.StatusCode, Err: err, retryable: true}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Err:        errors.New(strings.TrimSpace(string(respBody))),
+			retryable: resp.StatusCode == http.StatusRequestTimeout ||
+				resp.StatusCode == http.StatusTooManyRequests ||
+				resp.StatusCode >= http.StatusInternalServerError,
+		}
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return responseError(err)
+	}
+	return nil
+}
+
+// APIError is returned when a request fails. It is retryable for transport failures and for
+// timeout, throttling and server error statuses, but not for answers that could not be understood.
+type APIError struct {
+	// StatusCode is the HTTP status of the answer, or 0 when none was received.
+	StatusCode int
+	Err        error
+	retryable  bool
+}
+
+// responseError reports a successful answer whose content could not be used.
+func responseError(err error) *APIError {
+	return &APIError{StatusCode: http.StatusOK, Err: fmt.Errorf("unexpected API response: %w", err)}
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 || e.StatusCode == http.StatusOK {
+		return fmt.Sprintf("API request failed: %v", e.Err)
+	}
+	return fmt.Sprintf("API request failed with status code %d: %v", e.StatusCode, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether repeating the request may succeed.
+func (e *APIError) Retryable() bool {
+	return e.retryable
+}
@@ -0,0 +1,93 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// embeddingsServer answers every embeddings request with [len(input[i]), i] vectors, in reverse
+// order to check that results are matched by index.
+func embeddingsServer(t *testing.T, requests *[]embeddingsRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("unexpected Authorization header %q", auth)
+		}
+		var request embeddingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		*requests = append(*requests, request)
+
+		var response embeddingsResponse
+		for i := len(request.Input) - 1; i >= 0; i-- {
+			response.Data = append(response.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{i, []float32{float32(len(request.Input[i])), float32(i)}})
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEmbedder_BatchesAndOrdersVectors(t *testing.T) {
+	var requests []embeddingsRequest
+	server := embeddingsServer(t, &requests)
+	embedder := NewEmbedder(NewClient(server.URL+"/v1/", "secret", nil), "nomic-embed-text")
+	embedder.BatchSize = 2
+
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(requests) != 2 || requests[0].Model != "nomic-embed-text" || len(requests[1].Input) != 1 {
+		t.Errorf("unexpected requests %+v", requests)
+	}
+	for i, want := range []float32{1, 2, 3} {
+		if vectors[i][0] != want {
+			t.Errorf("vector %d: want %v first, got %v", i, want, vectors[i])
+		}
+	}
+}
+
+func TestClient_StatusErrors(t *testing.T) {
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", status)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "", nil)
+
+	_, err := client.Embeddings(context.Background(), "m", []string{"a"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != status || !apiErr.Retryable() {
+		t.Errorf("want a retryable APIError, got %v", err)
+	}
+
+	status = http.StatusBadRequest
+	if _, err := client.Embeddings(context.Background(), "m", []string{"a"}); !errors.As(err, &apiErr) || apiErr.Retryable() {
+		t.Errorf("want a permanent APIError, got %v", err)
+	}
+}
+
+func TestClient_RejectsMissingEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"index":0,"embedding":[0.5]}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "", nil).Embeddings(context.Background(), "m", []string{"a", "b"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Retryable() {
+		t.Errorf("want a permanent APIError, got %v", err)
+	}
+}
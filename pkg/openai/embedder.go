@@ -0,0 +1,43 @@
+package openai
+
+import (
+	"context"
+)
+
+const (
+	// DEFAULT_EMBEDDINGS_MODEL is the embedding model requested when none is configured. Servers
+	// that host a single model, such as llama.cpp, ignore it.
+	DEFAULT_EMBEDDINGS_MODEL = "text-embedding-3-small"
+	// DEFAULT_EMBEDDINGS_BATCH_SIZE is the number of texts sent per embeddings request.
+	DEFAULT_EMBEDDINGS_BATCH_SIZE = 32
+)
+
+// Embedder implements src.Embedder with the embeddings endpoint of an OpenAI-compatible API.
+type Embedder struct {
+	client *Client
+	Model  string
+	// BatchSize is the number of texts sent per request; 0 or less means DEFAULT_EMBEDDINGS_BATCH_SIZE.
+	BatchSize int
+}
+
+// NewEmbedder creates an Embedder that embeds with model.
+func NewEmbedder(client *Client, model string) *Embedder {
+	return &Embedder{client: client, Model: model}
+}
+
+// Embed returns one vector per text, splitting the texts into batches of BatchSize.
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	size := e.BatchSize
+	if size <= 0 {
+		size = DEFAULT_EMBEDDINGS_BATCH_SIZE
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		batch, err := e.client.Embeddings(ctx, e.Model, texts[start:min(start+size, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
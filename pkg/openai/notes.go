@@ -0,0 +1,175 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+const (
+	// DEFAULT_NOTES_MODEL is the chat model requested when none is configured. Servers that host a
+	// single model, such as llama.cpp, ignore it.
+	DEFAULT_NOTES_MODEL = "gpt-4o-mini"
+	// DEFAULT_NOTES_MAX_CHARS is the longest transcript sent in one request, about 6000 tokens of
+	// English, which leaves room for the prompt and the answer in an 8k context.
+	DEFAULT_NOTES_MAX_CHARS = 24000
+)
+
+// DEFAULT_NOTES_PROMPT is the prompt template used when none is configured. It is executed with
+// NotesPromptData.
+const DEFAULT_NOTES_PROMPT = `Write notes on the video transcript below in Markdown, in the language of the transcript, with exactly these sections:
+
+## Summary
+A short paragraph on what the video is about.
+
+## Key points
+A bulleted list of the main ideas, facts and arguments.
+
+## Action items
+A bulleted list of the concrete steps the video recommends, or "None." if there are none.
+
+Answer with the notes only.
+{{if .Partial}}
+The transcript is too long to send at once, so below are summaries of its consecutive parts instead.
+{{end}}
+---
+{{.Transcript}}`
+
+// notesMapPrompt asks for the summary of one part of a transcript that does not fit the model context.
+const notesMapPrompt = `Below is part %d of %d of a video transcript. Summarize it in a few paragraphs, keeping every key point, fact, name, number and recommendation, so the summaries of all parts can be combined into notes on the whole video. Answer with the summary only.
+
+---
+%s`
+
+// NotesPromptData is the data a notes prompt template is executed with.
+type NotesPromptData struct {
+	// Transcript is the transcript text, or the summaries of its parts when Partial is set.
+	Transcript string
+	Partial    bool
+}
+
+// ParseNotesPrompt parses a notes prompt template and checks that it executes with NotesPromptData.
+func ParseNotesPrompt(text string) (*template.Template, error) {
+	prompt, err := template.New("notes").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notes prompt: %w", err)
+	}
+	if err := prompt.Execute(&strings.Builder{}, NotesPromptData{}); err != nil {
+		return nil, fmt.Errorf("invalid notes prompt: %w", err)
+	}
+	return prompt, nil
+}
+
+var defaultNotesPrompt = template.Must(ParseNotesPrompt(DEFAULT_NOTES_PROMPT))
+
+// NotesWriter implements src.NotesGenerator with the chat completions endpoint of an
+// OpenAI-compatible API. Transcripts longer than MaxChars are summarized map-reduce style: each
+// part is summarized on its own and the notes are written from the summaries.
+type NotesWriter struct {
+	client *Client
+	Model  string
+	// Prompt is executed with NotesPromptData; nil means DEFAULT_NOTES_PROMPT.
+	Prompt *template.Template
+	// MaxChars is the longest text sent in one request; 0 or less means DEFAULT_NOTES_MAX_CHARS.
+	MaxChars int
+}
+
+// NewNotesWriter creates a NotesWriter that writes with model and the default prompt.
+func NewNotesWriter(client *Client, model string) *NotesWriter {
+	return &NotesWriter{client: client, Model: model}
+}
+
+// Notes returns Markdown notes with a summary, key points and action items for transcript.
+func (w *NotesWriter) Notes(ctx context.Context, transcript string) (string, error) {
+	maxChars := w.MaxChars
+	if maxChars <= 0 {
+		maxChars = DEFAULT_NOTES_MAX_CHARS
+	}
+	prompt := w.Prompt
+	if prompt == nil {
+		prompt = defaultNotesPrompt
+	}
+
+	data := NotesPromptData{Transcript: strings.TrimSpace(transcript)}
+	if data.Transcript == "" {
+		return "", errors.New("transcript is empty")
+	}
+	for len(data.Transcript) > maxChars {
+		parts := splitText(data.Transcript, maxChars)
+		summaries := make([]string, len(parts))
+		for i, part := range parts {
+			summary, err := w.complete(ctx, fmt.Sprintf(notesMapPrompt, i+1, len(parts), part))
+			if err != nil {
+				return "", fmt.Errorf("failed to summarize part %d of %d: %w", i+1, len(parts), err)
+			}
+			summaries[i] = summary
+		}
+		reduced := strings.Join(summaries, "\n\n")
+		if len(reduced) >= len(data.Transcript) {
+			return "", fmt.Errorf("summaries of %d parts are no shorter than the text they summarize", len(parts))
+		}
+		data = NotesPromptData{Transcript: reduced, Partial: true}
+	}
+
+	var request strings.Builder
+	if err := prompt.Execute(&request, data); err != nil {
+		return "", fmt.Errorf("failed to render notes prompt: %w", err)
+	}
+	notes, err := w.complete(ctx, request.String())
+	if err != nil {
+		return "", err
+	}
+	return notes + "\n", nil
+}
+
+// complete sends prompt as a single user message and returns the trimmed answer.
+func (w *NotesWriter) complete(ctx context.Context, prompt string) (string, error) {
+	answer, err := w.client.ChatCompletion(ctx, w.Model, []ChatMessage{{Role: "user", Content: prompt}})
+	if err != nil {
+		return "", err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", responseError(errors.New("empty chat completion"))
+	}
+	return answer, nil
+}
+
+// splitText cuts text into chunks of at most maxChars bytes at line breaks. Lines longer than
+// maxChars are cut at the last space that fits, or mid-word when there is none.
+func splitText(text string, maxChars int) []string {
+	var chunks []string
+	var chunk strings.Builder
+	flush := func() {
+		if trimmed := strings.TrimSpace(chunk.String()); trimmed != "" {
+			chunks = append(chunks, trimmed)
+		}
+		chunk.Reset()
+	}
+	for _, line := range strings.Split(text, "\n") {
+		for len(line) > maxChars {
+			cut := strings.LastIndexByte(line[:maxChars], ' ')
+			if cut <= 0 {
+				cut = maxChars
+				for cut > 1 && !utf8.RuneStart(line[cut]) {
+					cut--
+				}
+			}
+			flush()
+			chunks = append(chunks, line[:cut])
+			line = strings.TrimLeft(line[cut:], " ")
+		}
+		if chunk.Len() > 0 && chunk.Len()+1+len(line) > maxChars {
+			flush()
+		}
+		if chunk.Len() > 0 {
+			chunk.WriteByte('\n')
+		}
+		chunk.WriteString(line)
+	}
+	flush()
+	return chunks
+}
@@ -0,0 +1,145 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// chatServer answers every chat completion with answer(prompt) and records the prompts.
+func chatServer(t *testing.T, prompts *[]string, answer func(prompt string) string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var request chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if request.Model != "llama-3.1-8b" || len(request.Messages) != 1 || request.Messages[0].Role != "user" {
+			t.Errorf("unexpected request %+v", request)
+		}
+		prompt := request.Messages[0].Content
+		*prompts = append(*prompts, prompt)
+
+		var response chatCompletionResponse
+		response.Choices = append(response.Choices, struct {
+			Message ChatMessage `json:"message"`
+		}{ChatMessage{Role: "assistant", Content: answer(prompt)}})
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNotesWriter_ShortTranscript(t *testing.T) {
+	var prompts []string
+	server := chatServer(t, &prompts, func(string) string { return "\n## Summary\nA greeting.\n\n" })
+	writer := NewNotesWriter(NewClient(server.URL+"/v1", "", nil), "llama-3.1-8b")
+
+	notes, err := writer.Notes(context.Background(), "Hello\nworld")
+	if err != nil {
+		t.Fatalf("Notes failed: %v", err)
+	}
+	if notes != "## Summary\nA greeting.\n" {
+		t.Errorf("unexpected notes %q", notes)
+	}
+	if len(prompts) != 1 || !strings.HasSuffix(prompts[0], "---\nHello\nworld") || strings.Contains(prompts[0], "summaries of its consecutive parts") {
+		t.Errorf("unexpected prompts %q", prompts)
+	}
+}
+
+func TestNotesWriter_MapReducesLongTranscript(t *testing.T) {
+	var prompts []string
+	server := chatServer(t, &prompts, func(prompt string) string {
+		if strings.HasPrefix(prompt, "Below is part") {
+			return "summary"
+		}
+		return "## Summary\nAll parts."
+	})
+	writer := NewNotesWriter(NewClient(server.URL+"/v1", "", nil), "llama-3.1-8b")
+	writer.MaxChars = 30
+
+	notes, err := writer.Notes(context.Background(), "first line of speech\nsecond line of speech\nthird line")
+	if err != nil {
+		t.Fatalf("Notes failed: %v", err)
+	}
+	if notes != "## Summary\nAll parts.\n" {
+		t.Errorf("unexpected notes %q", notes)
+	}
+	if len(prompts) != 4 || !strings.HasPrefix(prompts[0], "Below is part 1 of 3") || !strings.HasSuffix(prompts[2], "---\nthird line") {
+		t.Fatalf("unexpected prompts %q", prompts)
+	}
+	if final := prompts[3]; !strings.Contains(final, "summaries of its consecutive parts") || !strings.HasSuffix(final, "---\nsummary\n\nsummary\n\nsummary") {
+		t.Errorf("unexpected final prompt %q", final)
+	}
+}
+
+func TestNotesWriter_FailsWhenSummariesDoNotShrink(t *testing.T) {
+	var prompts []string
+	server := chatServer(t, &prompts, func(prompt string) string { return prompt })
+	writer := NewNotesWriter(NewClient(server.URL+"/v1", "", nil), "llama-3.1-8b")
+	writer.MaxChars = 20
+
+	if _, err := writer.Notes(context.Background(), "first line of speech\nsecond line of speech"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestNotesWriter_CustomPrompt(t *testing.T) {
+	prompt, err := ParseNotesPrompt("Notes please:\n{{.Transcript}}")
+	if err != nil {
+		t.Fatalf("ParseNotesPrompt failed: %v", err)
+	}
+	var prompts []string
+	server := chatServer(t, &prompts, func(string) string { return "notes" })
+	writer := NewNotesWriter(NewClient(server.URL+"/v1", "", nil), "llama-3.1-8b")
+	writer.Prompt = prompt
+
+	if _, err := writer.Notes(context.Background(), "Hello"); err != nil {
+		t.Fatalf("Notes failed: %v", err)
+	}
+	if len(prompts) != 1 || prompts[0] != "Notes please:\nHello" {
+		t.Errorf("unexpected prompts %q", prompts)
+	}
+
+	if _, err := ParseNotesPrompt("{{.Title}}"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestClient_RejectsEmptyChatCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "", nil).ChatCompletion(context.Background(), "m", []ChatMessage{{Role: "user", Content: "hi"}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Retryable() {
+		t.Errorf("want a permanent APIError, got %v", err)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want []string
+	}{
+		{"ab\ncd\nef", []string{"ab\ncd", "ef"}},
+		{"one two three", []string{"one", "two", "three"}},
+		{"abcdefgh", []string{"abcde", "fgh"}},
+		{"hhéééé", []string{"hhé", "éé", "é"}},
+		{"ab\n\n\ncd", []string{"ab", "cd"}},
+	} {
+		got := splitText(tc.text, 5)
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("splitText(%q): want %q, got %q", tc.text, tc.want, got)
+		}
+	}
+}
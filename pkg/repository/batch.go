@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Values stored in reprocess_batch_items.status.
+const (
+	BATCH_ITEM_SUCCEEDED = "succeeded"
+	BATCH_ITEM_UNCHANGED = "unchanged"
+	BATCH_ITEM_FAILED    = "failed"
+	BATCH_ITEM_SKIPPED   = "skipped"
+)
+
+// Batch is one -reprocess-all run recorded in reprocess_batches.
+type Batch struct {
+	ID     int64
+	Filter ReprocessFilter
+	// Total is the number of rows the filter matched when the batch was created.
+	Total int
+	// Processed is the number of items recorded for the batch so far.
+	Processed int
+	// Cursor is the position of the last processed item, or nil when none has been processed yet.
+	Cursor    *Cursor
+	CreatedAt time.Time
+}
+
+// BatchItem is the outcome of one media item processed by a batch.
+type BatchItem struct {
+	MediaItemID string
+	// CreatedAt is the created_at of the media item; with MediaItemID it becomes the batch cursor.
+	CreatedAt time.Time
+	// Status is one of the BATCH_ITEM_* constants.
+	Status string
+	// Error is the reason of a failed or skipped item.
+	Error string
+}
+
+// BatchRepository records reprocess batches so an interrupted run can be resumed.
+type BatchRepository interface {
+	// CreateBatch records a new batch over the rows matched by filter.
+	CreateBatch(ctx context.Context, filter ReprocessFilter, total int) (*Batch, error)
+
+	// LatestUnfinishedBatch returns the most recently created batch that was not finished.
+	// Returns nil, nil when every batch has finished.
+	LatestUnfinishedBatch(ctx context.Context) (*Batch, error)
+
+	// RecordBatchItem stores the outcome of an item and advances the batch cursor past it.
+	RecordBatchItem(ctx context.Context, batchID int64, item BatchItem) error
+
+	// FinishBatch marks the batch as finished, so it is no longer resumed.
+	FinishBatch(ctx context.Context, batchID int64) error
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReprocessFilter selects the media_items rows to reprocess. The zero value matches every row;
+// each set field narrows the selection further. Batches store the filter as JSON, without the
+// cursor and limit.
+type ReprocessFilter struct {
+	// Platforms matches rows whose platform is one of the values.
+	Platforms []string `json:"platforms,omitempty"`
+	// IDs matches rows whose id is one of the values.
+	IDs []string `json:"ids,omitempty"`
+	// CreatedFrom and CreatedTo match rows created at or after CreatedFrom and before CreatedTo.
+	CreatedFrom time.Time `json:"createdFrom,omitzero"`
+	CreatedTo   time.Time `json:"createdTo,omitzero"`
+	// Model matches rows whose transcript_provenance records this whisper model, e.g. "ggml-base.en.bin".
+	Model string `json:"model,omitempty"`
+	// ToolName and ToolVersion match rows whose transcript_provenance records this version of a tool,
+	// e.g. "yt-dlp" and "2025.01.15". ToolVersion requires ToolName.
+	ToolName    string `json:"toolName,omitempty"`
+	ToolVersion string `json:"toolVersion,omitempty"`
+	// Status matches rows with this transcript_status, one of the STATUS_* constants.
+	Status string `json:"status,omitempty"`
+	// FailedInBatch matches rows that failed in the reprocess batch with this id.
+	FailedInBatch int64 `json:"failedInBatch,omitempty"`
+	// After matches rows that come after this position in (created_at, id) order.
+	After *Cursor `json:"-"`
+	// Limit caps the number of rows returned; 0 means no limit.
+	Limit int `json:"-"`
+}
+
+// ParseDateBound parses a YYYY-MM-DD date or an RFC 3339 time for CreatedFrom or CreatedTo. A date
+// means UTC midnight of that day or, for an end bound, of the next day, so the end bound covers
+// the whole day.
+func ParseDateBound(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want YYYY-MM-DD or an RFC 3339 time, got %q", value)
+	}
+	return t, nil
+}
+
+// Cursor is a position in the (created_at, id) order in which rows are reprocessed.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Validate reports filters that cannot match as intended.
+func (f ReprocessFilter) Validate() error {
+	if f.Status != "" && !slices.Contains([]string{STATUS_COMPLETED, STATUS_RETRY, STATUS_FAILED, STATUS_SKIPPED}, f.Status) {
+		return fmt.Errorf("status must be %q, %q, %q or %q, got %q", STATUS_COMPLETED, STATUS_RETRY, STATUS_FAILED, STATUS_SKIPPED, f.Status)
+	}
+	if f.ToolVersion != "" && f.ToolName == "" {
+		return fmt.Errorf("a tool version needs a tool name")
+	}
+	if !f.CreatedFrom.IsZero() && !f.CreatedTo.IsZero() && !f.CreatedFrom.Before(f.CreatedTo) {
+		return fmt.Errorf("the created range is empty (%s to %s)", f.CreatedFrom.Format(time.RFC3339), f.CreatedTo.Format(time.RFC3339))
+	}
+	if f.FailedInBatch < 0 {
+		return fmt.Errorf("batch id must be positive")
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	return nil
+}
+
+// IsEmpty reports whether the filter matches every row.
+func (f ReprocessFilter) IsEmpty() bool {
+	return len(f.Platforms) == 0 && len(f.IDs) == 0 && f.CreatedFrom.IsZero() && f.CreatedTo.IsZero() &&
+		f.Model == "" && f.ToolName == "" && f.Status == "" && f.FailedInBatch == 0
+}
+
+// where returns the SQL condition for the filter and its arguments, numbered from $1.
+func (f ReprocessFilter) where() (string, []any) {
+	var c conditions
+	if len(f.Platforms) > 0 {
+		c.add("platform = ANY(?)", f.Platforms)
+	}
+	if len(f.IDs) > 0 {
+		c.add("id = ANY(?)", f.IDs)
+	}
+	if !f.CreatedFrom.IsZero() {
+		c.add("created_at >= ?", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		c.add("created_at < ?", f.CreatedTo)
+	}
+	if f.Model != "" {
+		c.add("transcript_provenance->>'model' = ?", f.Model)
+	}
+	if f.ToolName != "" && f.ToolVersion != "" {
+		c.add("transcript_provenance->'toolVersions'->>? = ?", f.ToolName, f.ToolVersion)
+	} else if f.ToolName != "" {
+		c.add("transcript_provenance->'toolVersions'->>? IS NOT NULL", f.ToolName)
+	}
+	if f.Status != "" {
+		c.add("transcript_status = ?", f.Status)
+	}
+	if f.FailedInBatch != 0 {
+		c.add(`id IN (SELECT media_item_id FROM reprocess_batch_items WHERE batch_id = ? AND status = ?)`, f.FailedInBatch, BATCH_ITEM_FAILED)
+	}
+	if f.After != nil {
+		c.add("(created_at, id) > (?, ?)", f.After.CreatedAt, f.After.ID)
+	}
+	return c.sql(), c.args
+}
+
+// conditions collects the conditions of a WHERE clause. Each "?" placeholder is replaced by the
+// next $n, so arguments are numbered in the order they are added.
+type conditions struct {
+	conds []string
+	args  []any
+}
+
+// arg appends value to the arguments and returns its placeholder.
+func (c *conditions) arg(value any) string {
+	c.args = append(c.args, value)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+// add appends cond with its "?" placeholders bound to values.
+func (c *conditions) add(cond string, values ...any) {
+	for _, v := range values {
+		cond = strings.Replace(cond, "?", c.arg(v), 1)
+	}
+	c.conds = append(c.conds, cond)
+}
+
+// sql returns the conditions joined with AND, or TRUE when there are none.
+func (c *conditions) sql() string {
+	if len(c.conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(c.conds, "\n\t\t  AND  ")
+}
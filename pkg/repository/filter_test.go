@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReprocessFilter_WhereEmptyMatchesEverything(t *testing.T) {
+	where, args := ReprocessFilter{}.where()
+	if where != "TRUE" || args != nil {
+		t.Errorf("want TRUE without args, got %q %v", where, args)
+	}
+	if !(ReprocessFilter{Limit: 5}).IsEmpty() {
+		t.Error("a limit alone must not count as a filter")
+	}
+}
+
+func TestReprocessFilter_WhereNumbersArguments(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := ReprocessFilter{
+		Platforms:   []string{"youtube", "instagram"},
+		CreatedFrom: from,
+		Model:       "ggml-base.en.bin",
+		ToolName:    "yt-dlp",
+		ToolVersion: "2025.01.15",
+		Status:      STATUS_FAILED,
+	}
+	where, args := filter.where()
+
+	for _, cond := range []string{
+		"platform = ANY($1)",
+		"created_at >= $2",
+		"transcript_provenance->>'model' = $3",
+		"transcript_provenance->'toolVersions'->>$4 = $5",
+		"transcript_status = $6",
+	} {
+		if !strings.Contains(where, cond) {
+			t.Errorf("want %q in %q", cond, where)
+		}
+	}
+	want := []any{[]string{"youtube", "instagram"}, from, "ggml-base.en.bin", "yt-dlp", "2025.01.15", STATUS_FAILED}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("want args %v, got %v", want, args)
+	}
+}
+
+func TestReprocessFilter_Validate(t *testing.T) {
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		filter  ReprocessFilter
+		wantErr bool
+	}{
+		{"empty", ReprocessFilter{}, false},
+		{"known status", ReprocessFilter{Status: STATUS_SKIPPED}, false},
+		{"unknown status", ReprocessFilter{Status: "broken"}, true},
+		{"version without tool", ReprocessFilter{ToolVersion: "1.0"}, true},
+		{"empty range", ReprocessFilter{CreatedFrom: day, CreatedTo: day}, true},
+		{"negative limit", ReprocessFilter{Limit: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.filter.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: want error %v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestReprocessFilter_WhereCursorAndFailedInBatch(t *testing.T) {
+	after := &Cursor{CreatedAt: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC), ID: "item-800"}
+	where, args := ReprocessFilter{FailedInBatch: 7, After: after}.where()
+
+	for _, cond := range []string{
+		"id IN (SELECT media_item_id FROM reprocess_batch_items WHERE batch_id = $1 AND status = $2)",
+		"(created_at, id) > ($3, $4)",
+	} {
+		if !strings.Contains(where, cond) {
+			t.Errorf("want %q in %q", cond, where)
+		}
+	}
+	want := []any{int64(7), BATCH_ITEM_FAILED, after.CreatedAt, "item-800"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("want args %v, got %v", want, args)
+	}
+}
+
+func TestReprocessFilter_JSONOmitsCursorAndLimit(t *testing.T) {
+	filter := ReprocessFilter{
+		Platforms:   []string{"youtube"},
+		CreatedFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Status:      STATUS_FAILED,
+		After:       &Cursor{ID: "x"},
+		Limit:       10,
+	}
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if want := `{"platforms":["youtube"],"createdFrom":"2025-01-01T00:00:00Z","status":"failed"}`; string(encoded) != want {
+		t.Errorf("want %s, got %s", want, encoded)
+	}
+
+	var decoded ReprocessFilter
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	filter.After, filter.Limit = nil, 0
+	if !reflect.DeepEqual(decoded, filter) {
+		t.Errorf("want %+v, got %+v", filter, decoded)
+	}
+}
+
+func TestParseDateBound(t *testing.T) {
+	tests := []struct {
+		value string
+		end   bool
+		want  time.Time
+	}{
+		{"2025-01-15", false, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"2025-01-15", true, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"2025-01-15T10:30:00Z", true, time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDateBound(tt.value, tt.end)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseDateBound(%q, %v): want %s, got %s (%v)", tt.value, tt.end, tt.want, got, err)
+		}
+	}
+	if _, err := ParseDateBound("15/01/2025", false); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"iter"
+)
+
+// DEFAULT_PAGE_SIZE is the number of rows Iterate fetches per query.
+const DEFAULT_PAGE_SIZE = 100
+
+// Iterate yields the rows matched by filter in (created_at, id) order. It fetches them from repo in
+// pages of pageSize with keyset pagination (each page starts after the last row of the previous one),
+// so memory use does not grow with the table and rows inserted while the caller works through the
+// earlier pages are still yielded. A positive filter.Limit caps the total number of rows; a
+// pageSize of 0 or less selects DEFAULT_PAGE_SIZE.
+//
+// A failed query is yielded as the error of a zero MediaItem and ends the iteration.
+func Iterate(ctx context.Context, repo MediaItemRepository, filter ReprocessFilter, pageSize int) iter.Seq2[MediaItem, error] {
+	if pageSize <= 0 {
+		pageSize = DEFAULT_PAGE_SIZE
+	}
+	return func(yield func(MediaItem, error) bool) {
+		remaining := filter.Limit
+		page := filter
+		for {
+			page.Limit = pageSize
+			if filter.Limit > 0 {
+				page.Limit = min(pageSize, remaining)
+			}
+			items, err := repo.FetchMatching(ctx, page)
+			if err != nil {
+				yield(MediaItem{}, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			remaining -= len(items)
+			if len(items) < page.Limit || (filter.Limit > 0 && remaining <= 0) {
+				return
+			}
+			last := items[len(items)-1]
+			page.After = &Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+	}
+}
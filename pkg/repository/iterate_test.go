@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// keysetRepo serves FetchMatching from a slice sorted by (created_at, id), honouring After and Limit.
+type keysetRepo struct {
+	mockRepo
+	rows    []MediaItem
+	queries int
+	// onQuery runs before each query; it may append rows to simulate concurrent inserts.
+	onQuery func(r *keysetRepo)
+	failAt  int
+}
+
+func (r *keysetRepo) FetchMatching(_ context.Context, filter ReprocessFilter) ([]MediaItem, error) {
+	r.queries++
+	if r.onQuery != nil {
+		r.onQuery(r)
+	}
+	if r.failAt != 0 && r.queries == r.failAt {
+		return nil, errors.New("connection reset")
+	}
+	var page []MediaItem
+	for _, item := range r.rows {
+		if a := filter.After; a != nil && !(item.CreatedAt.After(a.CreatedAt) || item.CreatedAt.Equal(a.CreatedAt) && item.ID > a.ID) {
+			continue
+		}
+		if filter.Limit > 0 && len(page) == filter.Limit {
+			break
+		}
+		page = append(page, item)
+	}
+	return page, nil
+}
+
+func keysetRows(n int) []MediaItem {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]MediaItem, n)
+	for i := range rows {
+		// Pairs of rows share a created_at, so the id has to break the tie.
+		rows[i] = MediaItem{ID: fmt.Sprintf("id-%03d", i), CreatedAt: start.Add(time.Duration(i/2) * time.Minute)}
+	}
+	return rows
+}
+
+func collectIDs(t *testing.T, seq func(func(MediaItem, error) bool)) []string {
+	t.Helper()
+	var ids []string
+	for item, err := range seq {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestIterate_PagesThroughAllRows(t *testing.T) {
+	repo := &keysetRepo{rows: keysetRows(7)}
+	ids := collectIDs(t, Iterate(context.Background(), repo, ReprocessFilter{}, 3))
+
+	if len(ids) != 7 || ids[0] != "id-000" || ids[6] != "id-006" {
+		t.Errorf("unexpected ids %v", ids)
+	}
+	if repo.queries != 3 {
+		t.Errorf("want 3 page queries, got %d", repo.queries)
+	}
+}
+
+func TestIterate_YieldsRowsInsertedDuringIteration(t *testing.T) {
+	repo := &keysetRepo{rows: keysetRows(4)}
+	repo.onQuery = func(r *keysetRepo) {
+		if r.queries == 2 {
+			r.rows = append(r.rows, MediaItem{ID: "late", CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+		}
+	}
+	ids := collectIDs(t, Iterate(context.Background(), repo, ReprocessFilter{}, 2))
+
+	if len(ids) != 5 || ids[4] != "late" {
+		t.Errorf("expected the late row at the end, got %v", ids)
+	}
+}
+
+func TestIterate_HonoursLimitAndEarlyBreak(t *testing.T) {
+	repo := &keysetRepo{rows: keysetRows(10)}
+	if ids := collectIDs(t, Iterate(context.Background(), repo, ReprocessFilter{Limit: 5}, 2)); len(ids) != 5 {
+		t.Errorf("want 5 rows, got %v", ids)
+	}
+
+	repo.queries = 0
+	for range Iterate(context.Background(), repo, ReprocessFilter{}, 2) {
+		break
+	}
+	if repo.queries != 1 {
+		t.Errorf("breaking out must stop fetching, got %d queries", repo.queries)
+	}
+}
+
+func TestIterate_YieldsQueryError(t *testing.T) {
+	repo := &keysetRepo{rows: keysetRows(6), failAt: 2}
+	var ids []string
+	var gotErr error
+	for item, err := range Iterate(context.Background(), repo, ReprocessFilter{}, 2) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		ids = append(ids, item.ID)
+	}
+	if gotErr == nil || len(ids) != 2 {
+		t.Errorf("want the first page and then an error, got %v and %v", ids, gotErr)
+	}
+}
@@ -1,6 +1,18 @@
 package repository
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Values stored in media_items.transcript_status. A NULL status means the row has never been attempted.
+const (
+	STATUS_COMPLETED = "completed"
+	STATUS_RETRY     = "retry"
+	STATUS_FAILED    = "failed"
+	STATUS_SKIPPED   = "skipped"
+)
 
 // MediaItem represents an unprocessed row from the media_items table.
 // Only the fields needed by the transcription pipeline are mapped here.
@@ -9,18 +21,83 @@ type MediaItem struct {
 	URL      string
 	Platform string
 	VideoID  string
+	// Attempts is the number of failed transcription attempts recorded for this row.
+	Attempts int
+	// TranscriptURL and TranscriptSHA256 describe the stored transcript; empty when there is none.
+	// They are only loaded by FetchAll and FetchMatching.
+	TranscriptURL    string
+	TranscriptSHA256 string
+	// AudioURL is the archived Opus audio of the item, empty when it was not archived.
+	// It is only loaded by FetchAll and FetchMatching.
+	AudioURL string
+	// NotesURL is the Markdown notes of the item, empty when none were written.
+	// It is only loaded by FetchAll and FetchMatching.
+	NotesURL string
+	// CreatedAt is only loaded by FetchAll and FetchMatching.
+	CreatedAt time.Time
+}
+
+// JobAttempt is one failed attempt of a pipeline stage (download, transcribe or upload)
+// recorded in the transcription_attempts table.
+type JobAttempt struct {
+	Stage   string
+	Attempt int
+	Error   string
+	At      time.Time
+}
+
+// Transcript is the outcome of a successful job, written back to its media_items row.
+type Transcript struct {
+	// URL is stored in transcript_url.
+	URL string
+	// Provenance is the JSON provenance record of the transcript (model, tool versions), or nil.
+	Provenance json.RawMessage
+	// SHA256 is the hex-encoded hash of the transcript content, stored in transcript_sha256.
+	SHA256 string
+	// AudioURL is the archived audio, stored in audio_url. Empty keeps the stored value.
+	AudioURL string
+	// NotesURL is the Markdown notes, stored in notes_url. Empty keeps the stored value, unless the
+	// stored transcript_sha256 differs from SHA256: notes on a different transcript are cleared.
+	NotesURL string
 }
 
 // MediaItemRepository defines the database operations needed by the transcription pipeline.
 type MediaItemRepository interface {
-	// FetchNextUnprocessed returns the oldest media_items row whose transcript_url is NULL.
+	// FetchNextUnprocessed returns the oldest media_items row whose transcript_url is NULL,
+	// skipping rows marked as permanently failed and rows whose next retry is still in the future.
 	// Returns nil, nil when there are no unprocessed items.
 	FetchNextUnprocessed(ctx context.Context) (*MediaItem, error)
 
-	// FetchAll returns every row in media_items ordered by created_at ASC.
-	// Used by the reprocess-all mode to regenerate transcripts for existing records.
+	// FetchAll returns every row in media_items ordered by created_at ASC. It loads the whole
+	// table at once; batch modes use Iterate instead.
 	FetchAll(ctx context.Context) ([]MediaItem, error)
 
-	// UpdateTranscriptURL writes the Vercel Blob URL back to transcript_url for the given row id.
-	UpdateTranscriptURL(ctx context.Context, id, transcriptURL string) error
+	// FetchMatching returns the rows matched by filter ordered by (created_at, id), loading the same
+	// fields as FetchAll. Iterate calls it page by page for the reprocess-all mode.
+	FetchMatching(ctx context.Context, filter ReprocessFilter) ([]MediaItem, error)
+
+	// CountMatching returns the number of rows matched by filter, ignoring filter.Limit.
+	CountMatching(ctx context.Context, filter ReprocessFilter) (int, error)
+
+	// UpdateTranscript writes the transcript URL back to transcript_url, the provenance record
+	// to transcript_provenance, the content hash to transcript_sha256, the archived audio URL
+	// to audio_url and the notes URL to notes_url, for the given row id, and marks the row as completed.
+	UpdateTranscript(ctx context.Context, id string, transcript Transcript) error
+
+	// MarkFailed records a permanent failure so the row is no longer picked up by FetchNextUnprocessed.
+	MarkFailed(ctx context.Context, id, reason string) error
+
+	// MarkSkipped records that the row was rejected by the pre-flight checks (e.g. too long, live stream)
+	// so it is no longer picked up by FetchNextUnprocessed.
+	MarkSkipped(ctx context.Context, id, reason string) error
+
+	// ScheduleRetry records a transient failure and defers the row until retryAt.
+	ScheduleRetry(ctx context.Context, id, reason string, retryAt time.Time) error
+
+	// RecordAttempts stores the failed stage attempts of a job run for the given row id.
+	RecordAttempts(ctx context.Context, id string, attempts []JobAttempt) error
+
+	// ListArtifactURLs returns every stored artifact URL (transcript_url, notes_url and audio_url) across
+	// all rows. Used by the gc mode to find orphaned objects.
+	ListArtifactURLs(ctx context.Context) ([]string, error)
 }
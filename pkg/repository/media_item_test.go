@@ -2,20 +2,38 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 // mockRepo is a test double for MediaItemRepository.
 type mockRepo struct {
-	fetchResult  *MediaItem
-	fetchErr     error
+	fetchResult    *MediaItem
+	fetchErr       error
 	fetchAllResult []MediaItem
 	fetchAllErr    error
-	updateErr    error
+	lastFilter     ReprocessFilter
+	updateErr      error
 
-	lastUpdateID  string
-	lastUpdateURL string
+	lastUpdateID         string
+	lastUpdateURL        string
+	lastUpdateProvenance string
+	lastUpdateSHA256     string
+	lastUpdateAudioURL   string
+	lastUpdateNotesURL   string
+
+	statusErr    error
+	lastStatusID string
+	lastStatus   string
+	lastReason   string
+	lastRetryAt  time.Time
+
+	recordedAttempts []JobAttempt
+
+	artifactURLs []string
 }
 
 func (m *mockRepo) FetchNextUnprocessed(_ context.Context) (*MediaItem, error) {
@@ -26,12 +44,57 @@ func (m *mockRepo) FetchAll(_ context.Context) ([]MediaItem, error) {
 	return m.fetchAllResult, m.fetchAllErr
 }
 
-func (m *mockRepo) UpdateTranscriptURL(_ context.Context, id, transcriptURL string) error {
+func (m *mockRepo) FetchMatching(_ context.Context, filter ReprocessFilter) ([]MediaItem, error) {
+	m.lastFilter = filter
+	return m.fetchAllResult, m.fetchAllErr
+}
+
+func (m *mockRepo) CountMatching(_ context.Context, filter ReprocessFilter) (int, error) {
+	m.lastFilter = filter
+	return len(m.fetchAllResult), m.fetchAllErr
+}
+
+func (m *mockRepo) UpdateTranscript(_ context.Context, id string, transcript Transcript) error {
 	m.lastUpdateID = id
-	m.lastUpdateURL = transcriptURL
+	m.lastUpdateURL = transcript.URL
+	m.lastUpdateProvenance = string(transcript.Provenance)
+	m.lastUpdateSHA256 = transcript.SHA256
+	m.lastUpdateAudioURL = transcript.AudioURL
+	m.lastUpdateNotesURL = transcript.NotesURL
 	return m.updateErr
 }
 
+func (m *mockRepo) MarkFailed(_ context.Context, id, reason string) error {
+	m.lastStatusID = id
+	m.lastStatus = STATUS_FAILED
+	m.lastReason = reason
+	return m.statusErr
+}
+
+func (m *mockRepo) MarkSkipped(_ context.Context, id, reason string) error {
+	m.lastStatusID = id
+	m.lastStatus = STATUS_SKIPPED
+	m.lastReason = reason
+	return m.statusErr
+}
+
+func (m *mockRepo) ScheduleRetry(_ context.Context, id, reason string, retryAt time.Time) error {
+	m.lastStatusID = id
+	m.lastStatus = STATUS_RETRY
+	m.lastReason = reason
+	m.lastRetryAt = retryAt
+	return m.statusErr
+}
+
+func (m *mockRepo) RecordAttempts(_ context.Context, _ string, attempts []JobAttempt) error {
+	m.recordedAttempts = append(m.recordedAttempts, attempts...)
+	return m.statusErr
+}
+
+func (m *mockRepo) ListArtifactURLs(_ context.Context) ([]string, error) {
+	return m.artifactURLs, m.fetchAllErr
+}
+
 // Verify mockRepo satisfies the interface at compile time.
 var _ MediaItemRepository = (*mockRepo)(nil)
 
@@ -90,12 +153,16 @@ func TestFetchNextUnprocessed_PropagatesError(t *testing.T) {
 	}
 }
 
-func TestUpdateTranscriptURL_StoresValues(t *testing.T) {
+func TestUpdateTranscript_StoresValues(t *testing.T) {
 	repo := &mockRepo{}
 	id := "abc-123"
 	blobURL := "https://blob.vercel-storage.com/yt-transcribe/youtube/dQw4w9WgXcQ"
+	provenance := `{"model":"ggml-base.en.bin","toolVersions":{"yt-dlp":"2025.01.15"}}`
+	sha := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	audioURL := blobURL + ".opus"
+	notesURL := blobURL + ".md"
 
-	if err := repo.UpdateTranscriptURL(context.Background(), id, blobURL); err != nil {
+	if err := repo.UpdateTranscript(context.Background(), id, Transcript{URL: blobURL, Provenance: json.RawMessage(provenance), SHA256: sha, AudioURL: audioURL, NotesURL: notesURL}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if repo.lastUpdateID != id {
@@ -104,13 +171,25 @@ func TestUpdateTranscriptURL_StoresValues(t *testing.T) {
 	if repo.lastUpdateURL != blobURL {
 		t.Errorf("URL: want %q, got %q", blobURL, repo.lastUpdateURL)
 	}
+	if repo.lastUpdateProvenance != provenance {
+		t.Errorf("Provenance: want %q, got %q", provenance, repo.lastUpdateProvenance)
+	}
+	if repo.lastUpdateSHA256 != sha {
+		t.Errorf("SHA256: want %q, got %q", sha, repo.lastUpdateSHA256)
+	}
+	if repo.lastUpdateAudioURL != audioURL {
+		t.Errorf("AudioURL: want %q, got %q", audioURL, repo.lastUpdateAudioURL)
+	}
+	if repo.lastUpdateNotesURL != notesURL {
+		t.Errorf("NotesURL: want %q, got %q", notesURL, repo.lastUpdateNotesURL)
+	}
 }
 
-func TestUpdateTranscriptURL_PropagatesError(t *testing.T) {
+func TestUpdateTranscript_PropagatesError(t *testing.T) {
 	expectedErr := errors.New("update failed")
 	repo := &mockRepo{updateErr: expectedErr}
 
-	err := repo.UpdateTranscriptURL(context.Background(), "id", "url")
+	err := repo.UpdateTranscript(context.Background(), "id", Transcript{URL: "url"})
 	if err == nil {
 		t.Fatal("expected an error, got nil")
 	}
@@ -167,3 +246,102 @@ func TestFetchAll_PropagatesError(t *testing.T) {
 		t.Errorf("want %v, got %v", expectedErr, err)
 	}
 }
+
+func TestMarkFailed_StoresReason(t *testing.T) {
+	repo := &mockRepo{}
+
+	if err := repo.MarkFailed(context.Background(), "abc-123", "video is private"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.lastStatusID != "abc-123" {
+		t.Errorf("ID: want %q, got %q", "abc-123", repo.lastStatusID)
+	}
+	if repo.lastStatus != STATUS_FAILED {
+		t.Errorf("status: want %q, got %q", STATUS_FAILED, repo.lastStatus)
+	}
+	if repo.lastReason != "video is private" {
+		t.Errorf("reason: want %q, got %q", "video is private", repo.lastReason)
+	}
+}
+
+func TestMarkSkipped_StoresReason(t *testing.T) {
+	repo := &mockRepo{}
+
+	if err := repo.MarkSkipped(context.Background(), "abc-123", "live streams cannot be transcribed"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.lastStatus != STATUS_SKIPPED {
+		t.Errorf("status: want %q, got %q", STATUS_SKIPPED, repo.lastStatus)
+	}
+	if repo.lastReason != "live streams cannot be transcribed" {
+		t.Errorf("reason: want %q, got %q", "live streams cannot be transcribed", repo.lastReason)
+	}
+}
+
+func TestScheduleRetry_StoresRetryTime(t *testing.T) {
+	repo := &mockRepo{}
+	retryAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := repo.ScheduleRetry(context.Background(), "abc-123", "rate limited", retryAt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.lastStatus != STATUS_RETRY {
+		t.Errorf("status: want %q, got %q", STATUS_RETRY, repo.lastStatus)
+	}
+	if !repo.lastRetryAt.Equal(retryAt) {
+		t.Errorf("retryAt: want %v, got %v", retryAt, repo.lastRetryAt)
+	}
+}
+
+func TestScheduleRetry_PropagatesError(t *testing.T) {
+	expectedErr := errors.New("update failed")
+	repo := &mockRepo{statusErr: expectedErr}
+
+	err := repo.ScheduleRetry(context.Background(), "id", "reason", time.Now())
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("want %v, got %v", expectedErr, err)
+	}
+}
+
+func TestRecordAttempts_StoresAttempts(t *testing.T) {
+	repo := &mockRepo{}
+	attempts := []JobAttempt{
+		{Stage: "download", Attempt: 1, Error: "HTTP Error 429", At: time.Now()},
+		{Stage: "download", Attempt: 2, Error: "HTTP Error 429", At: time.Now()},
+	}
+
+	if err := repo.RecordAttempts(context.Background(), "abc-123", attempts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.recordedAttempts) != len(attempts) {
+		t.Fatalf("want %d attempts, got %d", len(attempts), len(repo.recordedAttempts))
+	}
+	if repo.recordedAttempts[1].Attempt != 2 {
+		t.Errorf("attempt[1].Attempt: want 2, got %d", repo.recordedAttempts[1].Attempt)
+	}
+}
+
+func TestListArtifactURLs_ReturnsURLs(t *testing.T) {
+	urls := []string{"https://blob.example.com/yt-transcribe/youtube/a", "https://blob.example.com/yt-transcribe/youtube/a.md"}
+	repo := &mockRepo{artifactURLs: urls}
+
+	got, err := repo.ListArtifactURLs(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(urls) || got[0] != urls[0] || got[1] != urls[1] {
+		t.Errorf("want %v, got %v", urls, got)
+	}
+}
+
+func TestUpdateTranscriptQuery_ClearsNotesOfRowsWithoutHash(t *testing.T) {
+	// A row with a NULL transcript_sha256 makes "transcript_sha256 <> $3" NULL, which would keep
+	// notes on a transcript they were not written for.
+	if !strings.Contains(updateTranscriptQuery, "WHEN transcript_sha256 IS DISTINCT FROM $3 THEN NULL") {
+		t.Errorf("want a NULL-safe comparison of the stored hash in %q", updateTranscriptQuery)
+	}
+	args := transcriptArgs("abc-123", Transcript{URL: "https://blob.example.com/abc"})
+	if args[2] != nil || args[4] != nil {
+		t.Errorf("want an empty hash and notes URL sent as NULL, got %v", args)
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DEFAULT_SEMANTIC_LIMIT is the number of passages returned by a semantic search by default.
+const DEFAULT_SEMANTIC_LIMIT = 10
+
+// Passage is a chunk of a transcript with its embedding, stored in transcript_passages.
+type Passage struct {
+	Start     time.Duration
+	End       time.Duration
+	Text      string
+	Embedding []float32
+}
+
+// PassageMatch is a stored passage close to a semantic search query.
+type PassageMatch struct {
+	// Item has the ID, URL, Platform and VideoID of the video loaded.
+	Item  MediaItem
+	Title string
+	// Passage has no Embedding loaded.
+	Passage Passage
+	// Distance is the cosine distance between the passage and the query: 0 for the same
+	// direction, up to 2 for opposite ones.
+	Distance float64
+}
+
+// PassageStore stores transcript passages with their embeddings and finds the nearest ones.
+// Embeddings of different models are not comparable, so each is stored with its model.
+type PassageStore interface {
+	// SavePassages replaces the stored passages of the row id with passages embedded by model.
+	SavePassages(ctx context.Context, id, model string, passages []Passage) error
+
+	// HasPassages reports whether passages embedded by model are stored for the row id.
+	HasPassages(ctx context.Context, id, model string) (bool, error)
+
+	// NearestPassages returns the limit passages embedded by model that are closest to embedding,
+	// nearest first.
+	NearestPassages(ctx context.Context, model string, embedding []float32, limit int) ([]PassageMatch, error)
+}
+
+// nearestPassagesQuery returns the NearestPassages query for embeddings of dims dimensions. The
+// dimension is written into the SQL because a partial index is only used when its predicate and
+// expression match the query literally.
+func nearestPassagesQuery(dims int) string {
+	return fmt.Sprintf(`
+		SELECT m.id, m.url, m.platform, m.video_id, m.title,
+		       p.start_ms, p.end_ms, p.text, p.embedding::vector(%[1]d) <=> $1::vector(%[1]d) AS distance
+		FROM   transcript_passages p
+		JOIN   media_items m ON m.id = p.media_item_id
+		WHERE  vector_dims(p.embedding) = %[1]d AND p.model = $2
+		ORDER  BY distance
+		LIMIT  $3`, dims)
+}
+
+// vectorLiteral formats v as a pgvector text literal such as [0.1,-0.25,3].
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
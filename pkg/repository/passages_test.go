@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVectorLiteral(t *testing.T) {
+	if got := vectorLiteral([]float32{0.1, -0.25, 3}); got != "[0.1,-0.25,3]" {
+		t.Errorf("unexpected literal %s", got)
+	}
+	if got := vectorLiteral(nil); got != "[]" {
+		t.Errorf("unexpected literal %s", got)
+	}
+}
+
+func TestNearestPassagesQuery_MatchesIndexExpression(t *testing.T) {
+	query := nearestPassagesQuery(768)
+	// The predicate and expression of transcript_passages_embedding_768_idx
+	for _, want := range []string{"vector_dims(p.embedding) = 768", "p.embedding::vector(768) <=> $1::vector(768)"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("want %q in %q", want, query)
+		}
+	}
+}
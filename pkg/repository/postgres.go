@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -44,20 +47,23 @@ func (r *PostgresMediaItemRepository) Close(_ context.Context) error {
 	return nil
 }
 
-// FetchNextUnprocessed returns the oldest row in media_items where transcript_url IS NULL.
+// FetchNextUnprocessed returns the oldest row in media_items where transcript_url IS NULL
+// that has not failed permanently and is not waiting for a scheduled retry.
 // Returns nil, nil when every item has already been processed.
 func (r *PostgresMediaItemRepository) FetchNextUnprocessed(ctx context.Context) (*MediaItem, error) {
 	const query = `
-		SELECT id, url, platform, video_id
+		SELECT id, url, platform, video_id, transcript_attempts
 		FROM   media_items
 		WHERE  transcript_url IS NULL
+		  AND  (transcript_status IS NULL OR transcript_status = $1)
+		  AND  (next_attempt_at IS NULL OR next_attempt_at <= now())
 		ORDER  BY created_at ASC
 		LIMIT  1`
 
-	row := r.pool.QueryRow(ctx, query)
+	row := r.pool.QueryRow(ctx, query, STATUS_RETRY)
 
 	var item MediaItem
-	err := row.Scan(&item.ID, &item.URL, &item.Platform, &item.VideoID)
+	err := row.Scan(&item.ID, &item.URL, &item.Platform, &item.VideoID, &item.Attempts)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
@@ -69,21 +75,34 @@ func (r *PostgresMediaItemRepository) FetchNextUnprocessed(ctx context.Context)
 
 // FetchAll returns every row in media_items ordered by created_at ASC.
 func (r *PostgresMediaItemRepository) FetchAll(ctx context.Context) ([]MediaItem, error) {
-	const query = `
-		SELECT id, url, platform, video_id
+	return r.FetchMatching(ctx, ReprocessFilter{})
+}
+
+// FetchMatching returns the rows in media_items matched by filter, ordered by created_at ASC and id.
+func (r *PostgresMediaItemRepository) FetchMatching(ctx context.Context, filter ReprocessFilter) ([]MediaItem, error) {
+	where, args := filter.where()
+	query := `
+		SELECT id, url, platform, video_id, created_at,
+		       COALESCE(transcript_url, ''), COALESCE(transcript_sha256, ''), COALESCE(audio_url, ''),
+		       COALESCE(notes_url, '')
 		FROM   media_items
-		ORDER  BY created_at ASC`
+		WHERE  ` + where + `
+		ORDER  BY created_at ASC, id ASC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf("\n\t\tLIMIT  $%d", len(args))
+	}
 
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch all items: %w", err)
+		return nil, fmt.Errorf("failed to fetch items: %w", err)
 	}
 	defer rows.Close()
 
 	var items []MediaItem
 	for rows.Next() {
 		var item MediaItem
-		if err := rows.Scan(&item.ID, &item.URL, &item.Platform, &item.VideoID); err != nil {
+		if err := rows.Scan(&item.ID, &item.URL, &item.Platform, &item.VideoID, &item.CreatedAt, &item.TranscriptURL, &item.TranscriptSHA256, &item.AudioURL, &item.NotesURL); err != nil {
 			return nil, fmt.Errorf("failed to scan media item row: %w", err)
 		}
 		items = append(items, item)
@@ -94,11 +113,28 @@ func (r *PostgresMediaItemRepository) FetchAll(ctx context.Context) ([]MediaItem
 	return items, nil
 }
 
-// UpdateTranscriptURL sets transcript_url for the row identified by id.
-func (r *PostgresMediaItemRepository) UpdateTranscriptURL(ctx context.Context, id, transcriptURL string) error {
-	const query = `UPDATE media_items SET transcript_url = $1 WHERE id = $2`
+// CountMatching returns the number of rows in media_items matched by filter, ignoring its Limit.
+func (r *PostgresMediaItemRepository) CountMatching(ctx context.Context, filter ReprocessFilter) (int, error) {
+	where, args := filter.where()
+	query := `
+		SELECT count(*)
+		FROM   media_items
+		WHERE  ` + where
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	return count, nil
+}
 
-	tag, err := r.pool.Exec(ctx, query, transcriptURL, id)
+// UpdateTranscript sets transcript_url, transcript_provenance, transcript_sha256, audio_url and notes_url
+// for the row identified by id and marks it completed, clearing any failure recorded by a previous attempt.
+// An empty AudioURL leaves audio_url unchanged, so a run without archiving keeps an earlier archive.
+// An empty NotesURL likewise keeps earlier notes, unless transcript_sha256 changes: notes on a
+// different transcript are cleared.
+func (r *PostgresMediaItemRepository) UpdateTranscript(ctx context.Context, id string, transcript Transcript) error {
+	tag, err := r.pool.Exec(ctx, updateTranscriptQuery, transcriptArgs(id, transcript)...)
 	if err != nil {
 		return fmt.Errorf("failed to update transcript_url for id %s: %w", id, err)
 	}
@@ -107,3 +143,396 @@ func (r *PostgresMediaItemRepository) UpdateTranscriptURL(ctx context.Context, i
 	}
 	return nil
 }
+
+// updateTranscriptQuery is the statement UpdateTranscript runs with transcriptArgs. Rows stored
+// before transcript_sha256 existed, and transcripts without a hash, have a NULL hash, so the
+// comparison must be NULL-safe for their notes to be cleared.
+const updateTranscriptQuery = `
+		UPDATE media_items
+		SET    transcript_url        = $1,
+		       transcript_provenance = $2,
+		       transcript_sha256     = $3,
+		       audio_url             = COALESCE($4, audio_url),
+		       notes_url             = CASE WHEN $5::text IS NOT NULL THEN $5
+		                                    WHEN transcript_sha256 IS DISTINCT FROM $3 THEN NULL
+		                                    ELSE notes_url END,
+		       transcript_status     = $6,
+		       transcript_error      = NULL,
+		       next_attempt_at       = NULL
+		WHERE  id = $7`
+
+// transcriptArgs returns the arguments of updateTranscriptQuery; empty values are sent as NULL.
+func transcriptArgs(id string, transcript Transcript) []any {
+	var provenance any
+	if len(transcript.Provenance) > 0 {
+		provenance = string(transcript.Provenance)
+	}
+	var sha any
+	if transcript.SHA256 != "" {
+		sha = transcript.SHA256
+	}
+	var audioURL any
+	if transcript.AudioURL != "" {
+		audioURL = transcript.AudioURL
+	}
+	var notesURL any
+	if transcript.NotesURL != "" {
+		notesURL = transcript.NotesURL
+	}
+	return []any{transcript.URL, provenance, sha, audioURL, notesURL, STATUS_COMPLETED, id}
+}
+
+// MarkFailed sets transcript_status to failed and stores the reason for the row identified by id.
+func (r *PostgresMediaItemRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	const query = `
+		UPDATE media_items
+		SET    transcript_status   = $1,
+		       transcript_error    = $2,
+		       transcript_attempts = transcript_attempts + 1,
+		       next_attempt_at     = NULL
+		WHERE  id = $3`
+
+	return r.execStatusUpdate(ctx, id, query, STATUS_FAILED, reason, id)
+}
+
+// MarkSkipped sets transcript_status to skipped and stores the rejection reason for the row identified by id.
+func (r *PostgresMediaItemRepository) MarkSkipped(ctx context.Context, id, reason string) error {
+	const query = `
+		UPDATE media_items
+		SET    transcript_status = $1,
+		       transcript_error  = $2,
+		       next_attempt_at   = NULL
+		WHERE  id = $3`
+
+	return r.execStatusUpdate(ctx, id, query, STATUS_SKIPPED, reason, id)
+}
+
+// ScheduleRetry sets transcript_status to retry and defers the row identified by id until retryAt.
+func (r *PostgresMediaItemRepository) ScheduleRetry(ctx context.Context, id, reason string, retryAt time.Time) error {
+	const query = `
+		UPDATE media_items
+		SET    transcript_status   = $1,
+		       transcript_error    = $2,
+		       transcript_attempts = transcript_attempts + 1,
+		       next_attempt_at     = $3
+		WHERE  id = $4`
+
+	return r.execStatusUpdate(ctx, id, query, STATUS_RETRY, reason, retryAt, id)
+}
+
+// RecordAttempts inserts one transcription_attempts row per failed attempt in a single round trip.
+func (r *PostgresMediaItemRepository) RecordAttempts(ctx context.Context, id string, attempts []JobAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	const query = `
+		INSERT INTO transcription_attempts (media_item_id, stage, attempt, error, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	batch := &pgx.Batch{}
+	for _, a := range attempts {
+		batch.Queue(query, id, a.Stage, a.Attempt, a.Error, a.At)
+	}
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to record attempts for id %s: %w", id, err)
+	}
+	return nil
+}
+
+// ListArtifactURLs returns the non-NULL transcript_url, notes_url and audio_url values of all rows.
+func (r *PostgresMediaItemRepository) ListArtifactURLs(ctx context.Context) ([]string, error) {
+	const query = `
+		SELECT a.url
+		FROM   media_items,
+		       LATERAL (VALUES (transcript_url), (notes_url), (audio_url)) AS a(url)
+		WHERE  a.url IS NOT NULL`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifact URLs: %w", err)
+	}
+	urls, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan artifact URLs: %w", err)
+	}
+	return urls, nil
+}
+
+// CreateBatch inserts a reprocess_batches row for filter, without its cursor and limit.
+func (r *PostgresMediaItemRepository) CreateBatch(ctx context.Context, filter ReprocessFilter, total int) (*Batch, error) {
+	const query = `
+		INSERT INTO reprocess_batches (filter, total)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch filter: %w", err)
+	}
+	batch := &Batch{Filter: filter, Total: total}
+	batch.Filter.After, batch.Filter.Limit = nil, 0
+	if err := r.pool.QueryRow(ctx, query, string(encoded), total).Scan(&batch.ID, &batch.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+	return batch, nil
+}
+
+// LatestUnfinishedBatch returns the newest reprocess_batches row whose finished_at is NULL,
+// with the number of items recorded for it.
+func (r *PostgresMediaItemRepository) LatestUnfinishedBatch(ctx context.Context) (*Batch, error) {
+	const query = `
+		SELECT b.id, b.filter, b.total, b.cursor_created_at, COALESCE(b.cursor_id, ''), b.created_at,
+		       (SELECT count(*) FROM reprocess_batch_items i WHERE i.batch_id = b.id)
+		FROM   reprocess_batches b
+		WHERE  b.finished_at IS NULL
+		ORDER  BY b.created_at DESC, b.id DESC
+		LIMIT  1`
+
+	var batch Batch
+	var filter []byte
+	var cursorCreatedAt *time.Time
+	var cursorID string
+	err := r.pool.QueryRow(ctx, query).Scan(&batch.ID, &filter, &batch.Total, &cursorCreatedAt, &cursorID, &batch.CreatedAt, &batch.Processed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unfinished batch: %w", err)
+	}
+	if err := json.Unmarshal(filter, &batch.Filter); err != nil {
+		return nil, fmt.Errorf("failed to decode filter of batch %d: %w", batch.ID, err)
+	}
+	if cursorCreatedAt != nil {
+		batch.Cursor = &Cursor{CreatedAt: *cursorCreatedAt, ID: cursorID}
+	}
+	return &batch, nil
+}
+
+// RecordBatchItem upserts the reprocess_batch_items row of item and moves the batch cursor to it,
+// in one statement so the cursor never runs ahead of the recorded outcomes.
+func (r *PostgresMediaItemRepository) RecordBatchItem(ctx context.Context, batchID int64, item BatchItem) error {
+	const query = `
+		WITH recorded AS (
+		  INSERT INTO reprocess_batch_items (batch_id, media_item_id, status, error)
+		  VALUES ($1, $2, $3, $4)
+		  ON CONFLICT (batch_id, media_item_id)
+		  DO UPDATE SET status = EXCLUDED.status, error = EXCLUDED.error, processed_at = now()
+		)
+		UPDATE reprocess_batches
+		SET    cursor_created_at = $5,
+		       cursor_id         = $2,
+		       updated_at        = now()
+		WHERE  id = $1`
+
+	var reason any
+	if item.Error != "" {
+		reason = item.Error
+	}
+	tag, err := r.pool.Exec(ctx, query, batchID, item.MediaItemID, item.Status, reason, item.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record item %s of batch %d: %w", item.MediaItemID, batchID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no batch found with id %d", batchID)
+	}
+	return nil
+}
+
+// FinishBatch sets finished_at on the batch.
+func (r *PostgresMediaItemRepository) FinishBatch(ctx context.Context, batchID int64) error {
+	const query = `
+		UPDATE reprocess_batches
+		SET    finished_at = now(),
+		       updated_at  = now()
+		WHERE  id = $1`
+
+	tag, err := r.pool.Exec(ctx, query, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to finish batch %d: %w", batchID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no batch found with id %d", batchID)
+	}
+	return nil
+}
+
+// SaveTranscriptText upserts the transcripts row of id and replaces its transcript_segments, in one
+// transaction so a search never sees the text of one transcript with the segments of another.
+func (r *PostgresMediaItemRepository) SaveTranscriptText(ctx context.Context, id string, text TranscriptText) error {
+	const upsert = `
+		INSERT INTO transcripts (media_item_id, text)
+		VALUES ($1, $2)
+		ON CONFLICT (media_item_id)
+		DO UPDATE SET text = EXCLUDED.text, updated_at = now()`
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, upsert, id, text.Text); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM transcript_segments WHERE media_item_id = $1`, id); err != nil {
+			return err
+		}
+		rows := make([][]any, len(text.Segments))
+		for i, s := range text.Segments {
+			rows[i] = []any{id, i, s.Start.Milliseconds(), s.End.Milliseconds(), s.Text}
+		}
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"transcript_segments"},
+			[]string{"media_item_id", "position", "start_ms", "end_ms", "text"}, pgx.CopyFromRows(rows))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save transcript text for id %s: %w", id, err)
+	}
+	return nil
+}
+
+// Search ranks the transcripts matching query with ts_rank and loads, for each of the videos on the
+// requested page, the first matching segments with their ts_headline snippets.
+func (r *PostgresMediaItemRepository) Search(ctx context.Context, query string, opts SearchOptions) (*SearchPage, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	opts = opts.normalized()
+
+	var c conditions
+	tsquery := c.arg(query)
+	headline := c.arg(fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxFragments=2, MaxWords=20, MinWords=8`, headlineStart, headlineStop))
+	hitHeadline := c.arg(fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, headlineStart, headlineStop))
+	where := opts.where(&c)
+	limit, offset, hits := c.arg(opts.Limit), c.arg(opts.Offset), c.arg(opts.Hits)
+
+	sql := `
+		WITH q AS (SELECT websearch_to_tsquery('english', ` + tsquery + `) AS query),
+		matches AS (
+		  SELECT m.id, m.url, m.platform, m.video_id, m.title, COALESCE(m.category, '') AS category,
+		         m.created_at, t.text, ts_rank(t.search, q.query) AS rank, count(*) OVER () AS total
+		  FROM   transcripts t
+		  JOIN   media_items m ON m.id = t.media_item_id, q
+		  WHERE  t.search @@ q.query
+		    AND  ` + where + `
+		  ORDER  BY rank DESC, m.id
+		  LIMIT  ` + limit + ` OFFSET ` + offset + `
+		)
+		SELECT matches.id, matches.url, matches.platform, matches.video_id, matches.title, matches.category,
+		       matches.created_at, matches.rank, matches.total,
+		       ts_headline('english', matches.text, q.query, ` + headline + `),
+		       h.start_ms, h.end_ms, h.text, h.snippet
+		FROM   matches
+		CROSS  JOIN q
+		LEFT   JOIN LATERAL (
+		  SELECT s.start_ms, s.end_ms, s.text,
+		         ts_headline('english', s.text, q.query, ` + hitHeadline + `) AS snippet
+		  FROM   transcript_segments s
+		  WHERE  s.media_item_id = matches.id AND s.search @@ q.query
+		  ORDER  BY s.position
+		  LIMIT  ` + hits + `
+		) h ON TRUE
+		ORDER  BY matches.rank DESC, matches.id, h.start_ms`
+
+	rows, err := r.pool.Query(ctx, sql, c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	var matched []searchRow
+	for rows.Next() {
+		var row searchRow
+		var startMS, endMS *int64
+		var hitText, hitSnippet *string
+		result := &row.result
+		if err := rows.Scan(&result.Item.ID, &result.Item.URL, &result.Item.Platform, &result.Item.VideoID, &result.Title,
+			&result.Category, &result.Item.CreatedAt, &result.Rank, &row.total, &result.Snippet,
+			&startMS, &endMS, &hitText, &hitSnippet); err != nil {
+			return nil, fmt.Errorf("failed to scan search row: %w", err)
+		}
+		result.Snippet = snippet(result.Snippet)
+		if startMS != nil {
+			row.hit = &SearchHit{
+				Segment: Segment{Start: time.Duration(*startMS) * time.Millisecond, End: time.Duration(*endMS) * time.Millisecond, Text: *hitText},
+				Snippet: snippet(*hitSnippet),
+			}
+		}
+		matched = append(matched, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search rows: %w", err)
+	}
+	return groupSearchRows(matched), nil
+}
+
+// SavePassages deletes the transcript_passages rows of id and inserts passages in their place, in
+// one transaction. Embeddings are sent as pgvector text literals.
+func (r *PostgresMediaItemRepository) SavePassages(ctx context.Context, id, model string, passages []Passage) error {
+	const insert = `
+		INSERT INTO transcript_passages (media_item_id, position, start_ms, end_ms, text, model, embedding)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::vector)`
+
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM transcript_passages WHERE media_item_id = $1`, id)
+	for i, p := range passages {
+		batch.Queue(insert, id, i, p.Start.Milliseconds(), p.End.Milliseconds(), p.Text, model, vectorLiteral(p.Embedding))
+	}
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save passages for id %s: %w", id, err)
+	}
+	return nil
+}
+
+// HasPassages reports whether transcript_passages has rows of model for id.
+func (r *PostgresMediaItemRepository) HasPassages(ctx context.Context, id, model string) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM transcript_passages WHERE media_item_id = $1 AND model = $2)`
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, query, id, model).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check passages for id %s: %w", id, err)
+	}
+	return exists, nil
+}
+
+// NearestPassages orders the passages of model by cosine distance (the pgvector <=> operator) to
+// embedding. The query is restricted to passages of the same dimension and casts them to it, so it
+// can use the HNSW index of that dimension from 009_transcript_passages_hnsw.sql.
+func (r *PostgresMediaItemRepository) NearestPassages(ctx context.Context, model string, embedding []float32, limit int) ([]PassageMatch, error) {
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("query embedding is empty")
+	}
+	rows, err := r.pool.Query(ctx, nearestPassagesQuery(len(embedding)), vectorLiteral(embedding), model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search passages: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []PassageMatch
+	for rows.Next() {
+		var match PassageMatch
+		var startMS, endMS int64
+		if err := rows.Scan(&match.Item.ID, &match.Item.URL, &match.Item.Platform, &match.Item.VideoID, &match.Title,
+			&startMS, &endMS, &match.Passage.Text, &match.Distance); err != nil {
+			return nil, fmt.Errorf("failed to scan passage row: %w", err)
+		}
+		match.Passage.Start = time.Duration(startMS) * time.Millisecond
+		match.Passage.End = time.Duration(endMS) * time.Millisecond
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating passage rows: %w", err)
+	}
+	return matches, nil
+}
+
+// execStatusUpdate runs a transcript_status update and reports a missing row as an error.
+func (r *PostgresMediaItemRepository) execStatusUpdate(ctx context.Context, id, query string, args ...any) error {
+	tag, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update transcript_status for id %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no row found with id %s", id)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"strings"
+	"time"
+)
+
+// Defaults of SearchOptions.
+const (
+	DEFAULT_SEARCH_LIMIT = 20
+	MAX_SEARCH_LIMIT     = 100
+	// DEFAULT_SEARCH_HITS is the number of matching segments returned per video.
+	DEFAULT_SEARCH_HITS = 5
+)
+
+// Segment is one timed cue of a transcript, stored in transcript_segments.
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// TranscriptText is the content of a transcript, stored for full-text search.
+type TranscriptText struct {
+	// Text is the plain text of the whole transcript, stored in transcripts.
+	Text     string
+	Segments []Segment
+}
+
+// Markers around the matched words in the snippets returned by Search. Snippets are HTML-escaped
+// transcript text, so these tags are the only markup in them.
+const (
+	SNIPPET_START = "<mark>"
+	SNIPPET_STOP  = "</mark>"
+)
+
+// ts_headline marks the matched words with these control characters instead of the snippet tags,
+// so the transcript text can be escaped before the tags are added.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+var snippetMarkers = strings.NewReplacer(headlineStart, SNIPPET_START, headlineStop, SNIPPET_STOP)
+
+// snippet HTML-escapes a ts_headline result and replaces its markers with the snippet tags.
+func snippet(headline string) string {
+	return snippetMarkers.Replace(html.EscapeString(headline))
+}
+
+// SearchOptions filters and pages the results of Search. Each set filter narrows the results.
+type SearchOptions struct {
+	// Platforms matches videos whose platform is one of the values.
+	Platforms []string
+	// Category matches videos with this category.
+	Category string
+	// CreatedFrom and CreatedTo match videos created at or after CreatedFrom and before CreatedTo.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// Limit is the number of videos returned; 0 selects DEFAULT_SEARCH_LIMIT. It is capped at
+	// MAX_SEARCH_LIMIT.
+	Limit  int
+	Offset int
+	// Hits is the number of matching segments returned per video; 0 selects DEFAULT_SEARCH_HITS.
+	Hits int
+}
+
+// normalized returns the options with the defaults applied.
+func (o SearchOptions) normalized() SearchOptions {
+	if o.Limit <= 0 {
+		o.Limit = DEFAULT_SEARCH_LIMIT
+	}
+	o.Limit = min(o.Limit, MAX_SEARCH_LIMIT)
+	o.Offset = max(o.Offset, 0)
+	if o.Hits <= 0 {
+		o.Hits = DEFAULT_SEARCH_HITS
+	}
+	return o
+}
+
+// where returns the condition on media_items m matching the filters of the options, with its
+// arguments bound after those already in c.
+func (o SearchOptions) where(c *conditions) string {
+	if len(o.Platforms) > 0 {
+		c.add("m.platform = ANY(?)", o.Platforms)
+	}
+	if o.Category != "" {
+		c.add("m.category = ?", o.Category)
+	}
+	if !o.CreatedFrom.IsZero() {
+		c.add("m.created_at >= ?", o.CreatedFrom)
+	}
+	if !o.CreatedTo.IsZero() {
+		c.add("m.created_at < ?", o.CreatedTo)
+	}
+	return c.sql()
+}
+
+// SearchHit is a segment matching a search query.
+type SearchHit struct {
+	Segment
+	// Snippet is the HTML-escaped text of the segment with the matched words between SNIPPET_START
+	// and SNIPPET_STOP.
+	Snippet string
+}
+
+// SearchResult is a video whose transcript matches a search query.
+type SearchResult struct {
+	// Item has the ID, URL, Platform, VideoID and CreatedAt of the video loaded.
+	Item     MediaItem
+	Title    string
+	Category string
+	// Rank orders the results; higher is more relevant.
+	Rank float64
+	// Snippet is up to two fragments of the transcript around the matched words, marked like the
+	// snippets of the hits.
+	Snippet string
+	// Hits are the matching segments in transcript order. A video can match without any hit when
+	// the query only matches across segment boundaries.
+	Hits []SearchHit
+}
+
+// SearchPage is one page of the results of Search.
+type SearchPage struct {
+	// Total is the number of videos matching the query and filters, over all pages. It is 0 when
+	// the page is past the last result.
+	Total   int
+	Results []SearchResult
+}
+
+// TranscriptSearcher stores transcript text and searches it.
+type TranscriptSearcher interface {
+	// SaveTranscriptText replaces the stored text and segments of the row id.
+	SaveTranscriptText(ctx context.Context, id string, text TranscriptText) error
+
+	// Search returns the page of videos whose transcript matches query, most relevant first, with
+	// the timestamps of the matching segments. query uses web search syntax: quoted phrases, OR and
+	// -excluded words.
+	Search(ctx context.Context, query string, opts SearchOptions) (*SearchPage, error)
+}
+
+// searchRow is one row of the search query: a video and one of its hits, if any.
+type searchRow struct {
+	result SearchResult
+	total  int
+	hit    *SearchHit
+}
+
+// groupSearchRows merges consecutive rows of the same video into one SearchResult.
+func groupSearchRows(rows []searchRow) *SearchPage {
+	page := &SearchPage{}
+	var results []SearchResult
+	for _, row := range rows {
+		page.Total = row.total
+		if n := len(results); n == 0 || results[n-1].Item.ID != row.result.Item.ID {
+			results = append(results, row.result)
+		}
+		if row.hit != nil {
+			last := &results[len(results)-1]
+			last.Hits = append(last.Hits, *row.hit)
+		}
+	}
+	page.Results = results
+	return page
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchOptions_Normalized(t *testing.T) {
+	if got := (SearchOptions{}).normalized(); !reflect.DeepEqual(got, SearchOptions{Limit: DEFAULT_SEARCH_LIMIT, Hits: DEFAULT_SEARCH_HITS}) {
+		t.Errorf("unexpected defaults %+v", got)
+	}
+	if got := (SearchOptions{Limit: 1000, Offset: -5, Hits: 2}).normalized(); !reflect.DeepEqual(got, SearchOptions{Limit: MAX_SEARCH_LIMIT, Hits: 2}) {
+		t.Errorf("unexpected options %+v", got)
+	}
+}
+
+func TestSearchOptions_WhereNumbersArgumentsAfterQuery(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var c conditions
+	c.arg("pgxpool")
+	where := SearchOptions{Platforms: []string{"youtube"}, Category: "Technology", CreatedFrom: from}.where(&c)
+
+	for _, cond := range []string{"m.platform = ANY($2)", "m.category = $3", "m.created_at >= $4"} {
+		if !strings.Contains(where, cond) {
+			t.Errorf("want %q in %q", cond, where)
+		}
+	}
+	if want := []any{"pgxpool", []string{"youtube"}, "Technology", from}; !reflect.DeepEqual(c.args, want) {
+		t.Errorf("want args %v, got %v", want, c.args)
+	}
+	if where := (SearchOptions{}).where(&conditions{}); where != "TRUE" {
+		t.Errorf("want TRUE without filters, got %q", where)
+	}
+}
+
+func TestGroupSearchRows(t *testing.T) {
+	first := SearchResult{Item: MediaItem{ID: "a"}, Rank: 0.9}
+	second := SearchResult{Item: MediaItem{ID: "b"}, Rank: 0.4}
+	rows := []searchRow{
+		{result: first, total: 12, hit: &SearchHit{Segment: Segment{Start: 3 * time.Second, Text: "pgxpool acquires"}}},
+		{result: first, total: 12, hit: &SearchHit{Segment: Segment{Start: 70 * time.Second, Text: "the pgxpool config"}}},
+		{result: second, total: 12},
+	}
+
+	page := groupSearchRows(rows)
+	if page.Total != 12 || len(page.Results) != 2 {
+		t.Fatalf("want 2 of 12 results, got %+v", page)
+	}
+	if r := page.Results[0]; r.Item.ID != "a" || len(r.Hits) != 2 || r.Hits[1].Start != 70*time.Second {
+		t.Errorf("unexpected first result %+v", r)
+	}
+	if r := page.Results[1]; r.Item.ID != "b" || r.Hits != nil {
+		t.Errorf("want the second result without hits, got %+v", r)
+	}
+	if page := groupSearchRows(nil); page.Total != 0 || page.Results != nil {
+		t.Errorf("want an empty page, got %+v", page)
+	}
+}
+
+func TestSnippet_EscapesTextBeforeMarking(t *testing.T) {
+	got := snippet("use <script>alert(1)</script> & \x02pgxpool\x03 \"now\"")
+	want := "use &lt;script&gt;alert(1)&lt;/script&gt; &amp; <mark>pgxpool</mark> &#34;now&#34;"
+	if got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
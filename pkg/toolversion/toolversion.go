@@ -0,0 +1,117 @@
+package toolversion
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PROBE_TIMEOUT bounds how long a single version probe may run.
+const PROBE_TIMEOUT = 10 * time.Second
+
+// maxVersionLength truncates unexpectedly long version lines (e.g. usage text printed instead of a version).
+const maxVersionLength = 120
+
+// rejectedOutputPattern matches the errors and usage text printed by programs that do not
+// understand the version option, which must not be recorded as a version.
+var rejectedOutputPattern = regexp.MustCompile(`(?i)^(usage|error)\b|\b(unknown|unrecognized|invalid) (argument|option)`)
+
+// commandOutput is a variable that can be overridden for testing purposes.
+var commandOutput = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, path, args...).CombinedOutput()
+}
+
+// Tool is an external program whose version is reported.
+type Tool struct {
+	Name string
+	// Path is the executable, either a name looked up on PATH or a file path.
+	Path string
+	// VersionArgs makes the program print its version, e.g. "--version".
+	VersionArgs []string
+}
+
+// Version is the detected version of a Tool. Error is set instead of Version when the probe failed.
+type Version struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Detect runs the version command of every tool and returns the results in the same order.
+// A tool that is missing or fails is reported with Error set; Detect itself never fails.
+func Detect(ctx context.Context, tools ...Tool) []Version {
+	versions := make([]Version, 0, len(tools))
+	for _, tool := range tools {
+		v := Version{Name: tool.Name, Path: tool.Path}
+		version, err := probe(ctx, tool)
+		if err != nil {
+			v.Error = err.Error()
+		} else {
+			v.Version = version
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func probe(ctx context.Context, tool Tool) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, PROBE_TIMEOUT)
+	defer cancel()
+
+	output, err := commandOutput(ctx, tool.Path, tool.VersionArgs...)
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed: %w", tool.Path, strings.Join(tool.VersionArgs, " "), err)
+	}
+	version := parseVersion(string(output))
+	if version == "" {
+		return "", fmt.Errorf("%s %s printed no version", tool.Path, strings.Join(tool.VersionArgs, " "))
+	}
+	return version, nil
+}
+
+// parseVersion returns the version from the first non-empty output line. Lines in the common
+// "<name> version <version> ..." form (ffmpeg, whisper.cpp) are reduced to the version itself.
+// Error and usage output, and lines without a digit, yield no version.
+func parseVersion(output string) string {
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if rejectedOutputPattern.MatchString(line) {
+			return ""
+		}
+		fields := strings.Fields(line)
+		for i := 0; i+1 < len(fields); i++ {
+			if strings.EqualFold(fields[i], "version") || strings.EqualFold(fields[i], "version:") {
+				line = fields[i+1]
+				break
+			}
+		}
+		if !strings.ContainsAny(line, "0123456789") {
+			return ""
+		}
+		if len(line) > maxVersionLength {
+			line = line[:maxVersionLength]
+		}
+		return line
+	}
+	return ""
+}
+
+// Map returns the versions keyed by tool name, using "unknown" for tools whose probe failed.
+func Map(versions []Version) map[string]string {
+	m := make(map[string]string, len(versions))
+	for _, v := range versions {
+		if v.Version != "" {
+			m[v.Name] = v.Version
+		} else {
+			m[v.Name] = "unknown"
+		}
+	}
+	return m
+}
@@ -0,0 +1,74 @@
+package toolversion
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"yt-dlp", "2025.01.15\n", "2025.01.15"},
+		{"ffmpeg", "ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023 the FFmpeg developers\nbuilt with gcc 13\n", "6.1.1-3ubuntu5"},
+		{"whisper-cli", "\nwhisper.cpp version: 1.7.4\n", "1.7.4"},
+		{"empty", "  \n", ""},
+		{"unknown argument", "error: unknown argument: --version\nusage: whisper-cli [options] file0 file1 ...\n", ""},
+		{"usage", "usage: whisper-cli [options] file0 file1 ...\n", ""},
+		{"unrecognized option", "whisper-cli: unrecognized option '--version'\n", ""},
+		{"no digits", "whisper-cli\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseVersion(tt.output); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	old := commandOutput
+	t.Cleanup(func() { commandOutput = old })
+	commandOutput = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+		switch path {
+		case "/opt/yt-dlp":
+			return []byte("2025.01.15\n"), nil
+		case "ffmpeg":
+			if len(args) != 1 || args[0] != "-version" {
+				t.Errorf("unexpected ffmpeg args: %v", args)
+			}
+			return []byte("ffmpeg version 7.0 Copyright"), nil
+		default:
+			return nil, errors.New("executable file not found in $PATH")
+		}
+	}
+
+	versions := Detect(context.Background(),
+		Tool{Name: "yt-dlp", Path: "/opt/yt-dlp", VersionArgs: []string{"--version"}},
+		Tool{Name: "ffmpeg", Path: "ffmpeg", VersionArgs: []string{"-version"}},
+		Tool{Name: "whisper-cli", Path: "whisper-cli", VersionArgs: []string{"--version"}},
+	)
+
+	if len(versions) != 3 {
+		t.Fatalf("want 3 versions, got %+v", versions)
+	}
+	if versions[0].Version != "2025.01.15" || versions[0].Path != "/opt/yt-dlp" {
+		t.Errorf("unexpected yt-dlp version: %+v", versions[0])
+	}
+	if versions[1].Version != "7.0" {
+		t.Errorf("unexpected ffmpeg version: %+v", versions[1])
+	}
+	if versions[2].Version != "" || versions[2].Error == "" {
+		t.Errorf("expected whisper-cli probe to fail, got %+v", versions[2])
+	}
+
+	m := Map(versions)
+	if m["yt-dlp"] != "2025.01.15" || m["whisper-cli"] != "unknown" {
+		t.Errorf("unexpected version map: %v", m)
+	}
+}
@@ -2,20 +2,49 @@ package transcriber
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 )
 
+// WHISPER_CLI_BINARY is the whisper.cpp executable used when no path is configured.
+const WHISPER_CLI_BINARY = "whisper-cli"
+
 var (
 	execLookPath = exec.LookPath
 	execCommand  = exec.CommandContext
 )
 
+// CommandError is returned when whisper-cli exits unsuccessfully. Such failures are
+// usually caused by resource pressure (e.g. the process being killed), so they are retryable.
+type CommandError struct {
+	Err    error
+	Output []byte
+}
+
+// Error implements the error interface.
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("failed to execute whisper-cli: %v\nOutput: %s", e.Err, e.Output)
+}
+
+// Unwrap returns the underlying process error.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports true unless the command was cancelled by the caller.
+func (e *CommandError) Retryable() bool {
+	return !errors.Is(e.Err, context.Canceled)
+}
+
 // WhisperCPPTranscriber implements the Transcriber interface using whisper.cpp.
 type WhisperCPPTranscriber struct {
 	ModelPath string
+	// BinaryPath is the whisper-cli executable, a name looked up on PATH or a file path.
+	// Empty means WHISPER_CLI_BINARY.
+	BinaryPath string
 }
 
 // NewWhisperCPPTranscriber creates a new WhisperCPPTranscriber.
@@ -27,9 +56,14 @@ func NewWhisperCPPTranscriber(modelPath string) *WhisperCPPTranscriber {
 
 // Transcribe transcribes the given audio file using whisper.cpp.
 func (t *WhisperCPPTranscriber) Transcribe(ctx context.Context, audioFilePath string) (string, error) {
+	binary := t.BinaryPath
+	if binary == "" {
+		binary = WHISPER_CLI_BINARY
+	}
+
 	// Check if whisper-cli is available
-	if _, err := execLookPath("whisper-cli"); err != nil {
-		return "", fmt.Errorf("whisper-cli not found in PATH: %w", err)
+	if _, err := execLookPath(binary); err != nil {
+		return "", fmt.Errorf("whisper-cli not found (%s): %w", binary, err)
 	}
 
 	// Create a temporary directory for output files
@@ -51,12 +85,15 @@ func (t *WhisperCPPTranscriber) Transcribe(ctx context.Context, audioFilePath st
 		"--no-prints",
 	}
 
-	cmd := execCommand(ctx, "whisper-cli", cmdArgs...)
+	cmd := execCommand(ctx, binary, cmdArgs...)
 
 	// Execute the command
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("failed to execute whisper-cli: %w\nOutput: %s", err, output)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return "", &CommandError{Err: err, Output: output}
 	}
 
 	// Read the transcribed text from the output file
@@ -79,6 +79,35 @@ func TestTranscribe_Success(t *testing.T) {
 	}
 }
 
+func TestTranscribe_UsesConfiguredBinary(t *testing.T) {
+	oldLookPath := execLookPath
+	oldCommand := execCommand
+	t.Cleanup(func() {
+		execLookPath = oldLookPath
+		execCommand = oldCommand
+	})
+
+	var looked, ran string
+	execLookPath = func(file string) (string, error) {
+		looked = file
+		return file, nil
+	}
+	execCommand = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		ran = name
+		cmd := oldCommand(ctx, os.Args[0], "-test.run=TestHelperProcessFailed")
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS_FAILED=1"}
+		return cmd
+	}
+
+	transcriber := NewWhisperCPPTranscriber("/path/to/model")
+	transcriber.BinaryPath = "/opt/whisper.cpp/build/bin/whisper-cli"
+	transcriber.Transcribe(context.Background(), "/path/to/audio.wav")
+
+	if looked != transcriber.BinaryPath || ran != transcriber.BinaryPath {
+		t.Errorf("expected %s to be looked up and run, got %q and %q", transcriber.BinaryPath, looked, ran)
+	}
+}
+
 // TestHelperProcess isn't a real test. It's used as a helper for other tests.
 func TestHelperProcess(t *testing.T) {
 	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
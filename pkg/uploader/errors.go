@@ -0,0 +1,70 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StatusError is returned when the storage API answers with an unexpected HTTP status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upload failed with status code %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the status indicates a transient condition
+// (request timeout, throttling or a server-side error).
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= http.StatusInternalServerError
+}
+
+// RequestError is returned when the request could not be sent or its response could not be read.
+type RequestError struct {
+	Op  string
+	Err error
+}
+
+// Error implements the error interface.
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying transport error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports true for transport failures, unless the request was cancelled by the caller.
+func (e *RequestError) Retryable() bool {
+	return !errors.Is(e.Err, context.Canceled)
+}
+
+// ResponseError is returned when the storage API accepted the upload but its response could not
+// be understood. It is permanent, so a broken response never ends up stored as a transcript URL.
+type ResponseError struct {
+	Body string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("unexpected upload response: %v: %q", e.Err, e.Body)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports false: repeating the upload would produce the same response.
+func (e *ResponseError) Retryable() bool {
+	return false
+}
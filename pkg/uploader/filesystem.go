@@ -0,0 +1,197 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemUploader implements the Uploader interface by writing artifacts below a local directory.
+// It is meant for offline development and tests, where the Blob API is not available.
+type FilesystemUploader struct {
+	root    string
+	baseURL string
+}
+
+// NewFilesystemUploader creates a FilesystemUploader that stores files below root. Uploaded files
+// are addressed as baseURL + "/" + filename when baseURL is set (e.g. when root is served over HTTP),
+// and as file:// URLs otherwise.
+func NewFilesystemUploader(root, baseURL string) *FilesystemUploader {
+	return &FilesystemUploader{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Upload writes content to root/filename, replacing any existing file. The result's Pathname is
+// the path relative to root. The file is written to a temporary file first and renamed, so readers
+// never see a partial file.
+func (f *FilesystemUploader) Upload(ctx context.Context, content string, filename string) (*UploadResult, error) {
+	return f.UploadReader(ctx, strings.NewReader(content), int64(len(content)), filename)
+}
+
+// UploadReader copies r to root/filename like Upload. size is ignored; the result reports the bytes written.
+func (f *FilesystemUploader) UploadReader(ctx context.Context, r io.Reader, size int64, filename string) (*UploadResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name, err := cleanStoragePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	target := filepath.Join(f.root, filepath.FromSlash(name))
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to set permissions on %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, fmt.Errorf("failed to move %s into place: %w", name, err)
+	}
+
+	fileURL, err := f.url(name, target)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadResult{
+		URL:         fileURL,
+		Pathname:    name,
+		Size:        written,
+		ContentType: contentTypeFor(name),
+	}, nil
+}
+
+// url returns the public URL of the file stored as name at target.
+func (f *FilesystemUploader) url(name, target string) (string, error) {
+	if f.baseURL != "" {
+		return f.baseURL + "/" + (&url.URL{Path: name}).EscapedPath(), nil
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", target, err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// cleanStoragePath normalises a slash-separated storage path and rejects paths that are empty,
+// absolute or that would escape the storage root.
+func cleanStoragePath(filename string) (string, error) {
+	name := path.Clean(strings.ReplaceAll(filename, "\\", "/"))
+	if name == "." || name == "/" || strings.HasPrefix(name, "/") || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("invalid storage path %q", filename)
+	}
+	return name, nil
+}
+
+// List returns the files below root whose storage path starts with prefix.
+func (f *FilesystemUploader) List(ctx context.Context, prefix string) ([]StoredObject, error) {
+	var objects []StoredObject
+	err := filepath.WalkDir(f.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(f.root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fileURL, err := f.url(name, p)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, StoredObject{Pathname: name, URL: fileURL, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", f.root, err)
+	}
+	return objects, nil
+}
+
+// Delete removes the file stored as obj.Pathname.
+func (f *FilesystemUploader) Delete(ctx context.Context, obj StoredObject) error {
+	name, err := cleanStoragePath(obj.Pathname)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(f.root, filepath.FromSlash(name))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+	return nil
+}
+
+// FileTransport returns a RoundTripper for the file:// URLs this uploader hands out, so that an
+// http.Client can download stored artifacts (e.g. archived audio) again. It serves files below
+// root only and answers 404 Not Found for any other path.
+func (f *FilesystemUploader) FileTransport() http.RoundTripper {
+	return &rootFileTransport{root: f.root, files: http.NewFileTransport(http.Dir(f.root))}
+}
+
+type rootFileTransport struct {
+	root  string
+	files http.RoundTripper
+}
+
+func (t *rootFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	abs, err := filepath.Abs(t.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", t.root, err)
+	}
+	rel, ok := strings.CutPrefix(req.URL.Path, filepath.ToSlash(abs)+"/")
+	if !ok {
+		return &http.Response{
+			Status:     "404 Not Found",
+			StatusCode: http.StatusNotFound,
+			Proto:      "HTTP/1.0",
+			ProtoMajor: 1,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+	scoped := req.Clone(req.Context())
+	scoped.URL.Path = "/" + rel
+	scoped.URL.RawPath = ""
+	return t.files.RoundTrip(scoped)
+}
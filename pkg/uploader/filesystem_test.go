@@ -0,0 +1,142 @@
+package uploader
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesystemUpload_WritesFileAndReturnsFileURL(t *testing.T) {
+	root := t.TempDir()
+	uploader := NewFilesystemUploader(root, "")
+
+	result, err := uploader.Upload(context.Background(), "1\n00:00:00,000 --> 00:00:01,000\nHello\n", "yt-transcribe/youtube/abc")
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	target := filepath.Join(root, "yt-transcribe", "youtube", "abc")
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("expected uploaded file at %s: %v", target, err)
+	}
+	if !strings.Contains(string(data), "Hello") {
+		t.Errorf("unexpected file content %q", data)
+	}
+	if want := "file://" + filepath.ToSlash(target); result.URL != want {
+		t.Errorf("want URL %q, got %q", want, result.URL)
+	}
+	if result.Pathname != "yt-transcribe/youtube/abc" || result.Size != int64(len(data)) || result.ContentType != DEFAULT_CONTENT_TYPE {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestFilesystemUpload_OverwritesAndUsesBaseURL(t *testing.T) {
+	root := t.TempDir()
+	uploader := NewFilesystemUploader(root, "http://localhost:3000/files/")
+
+	if _, err := uploader.Upload(context.Background(), "old", "yt-transcribe/other/my episode"); err != nil {
+		t.Fatalf("first Upload failed: %v", err)
+	}
+	result, err := uploader.Upload(context.Background(), "new", "yt-transcribe/other/my episode")
+	if err != nil {
+		t.Fatalf("second Upload failed: %v", err)
+	}
+
+	if want := "http://localhost:3000/files/yt-transcribe/other/my%20episode"; result.URL != want {
+		t.Errorf("want URL %q, got %q", want, result.URL)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "yt-transcribe", "other", "my episode")); string(data) != "new" {
+		t.Errorf("expected the file to be overwritten, got %q", data)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "yt-transcribe", "other"))
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
+
+func TestFilesystemUpload_RejectsPathsOutsideRoot(t *testing.T) {
+	uploader := NewFilesystemUploader(t.TempDir(), "")
+
+	for _, name := range []string{"../escape", "/etc/passwd", "", "a/../../b"} {
+		if _, err := uploader.Upload(context.Background(), "x", name); err == nil {
+			t.Errorf("expected an error for %q", name)
+		}
+	}
+}
+
+func TestFilesystemUploadReader_ReportsBytesWritten(t *testing.T) {
+	root := t.TempDir()
+	result, err := NewFilesystemUploader(root, "").UploadReader(context.Background(), strings.NewReader("opus data"), -1, "a/b.opus")
+	if err != nil {
+		t.Fatalf("UploadReader failed: %v", err)
+	}
+	if result.Size != int64(len("opus data")) || result.ContentType != "audio/ogg" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestFilesystem_ListAndDelete(t *testing.T) {
+	root := t.TempDir()
+	uploader := NewFilesystemUploader(root, "http://localhost:3000/files")
+	for _, name := range []string{"yt-transcribe/youtube/a", "yt-transcribe/other/b", "elsewhere/c"} {
+		if _, err := uploader.Upload(context.Background(), "x", name); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	objects, err := uploader.List(context.Background(), "yt-transcribe/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Pathname != "yt-transcribe/other/b" || objects[0].URL != "http://localhost:3000/files/yt-transcribe/other/b" {
+		t.Errorf("unexpected objects %+v", objects)
+	}
+
+	if err := uploader.Delete(context.Background(), objects[0]); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := uploader.Delete(context.Background(), objects[0]); err != nil {
+		t.Errorf("deleting a missing file should succeed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "yt-transcribe", "other", "b")); !os.IsNotExist(err) {
+		t.Errorf("expected the file to be deleted, got %v", err)
+	}
+}
+
+func TestFilesystem_FileTransportServesStoredFilesOnly(t *testing.T) {
+	root := t.TempDir()
+	uploader := NewFilesystemUploader(root, "")
+	result, err := uploader.Upload(context.Background(), "opus audio", "yt-transcribe/youtube/abc.opus")
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	transport := &http.Transport{}
+	transport.RegisterProtocol("file", uploader.FileTransport())
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(result.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "opus audio" {
+		t.Errorf("want stored file, got %d %q", resp.StatusCode, body)
+	}
+
+	outside := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(outside, []byte("secret"), 0644)
+	resp, err = client.Get("file://" + filepath.ToSlash(outside))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("want 404 outside root, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,418 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DEFAULT_S3_REGION is used when S3Options.Region is empty. MinIO accepts any region and R2 uses "auto".
+	DEFAULT_S3_REGION = "us-east-1"
+	// DEFAULT_CONTENT_TYPE is used for names without an extension, which is how transcripts are stored.
+	DEFAULT_CONTENT_TYPE = "text/plain; charset=utf-8"
+
+	// UNSIGNED_PAYLOAD replaces the payload hash of streamed uploads.
+	UNSIGNED_PAYLOAD = "UNSIGNED-PAYLOAD"
+
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// contentTypes maps the artifact extensions we store to their content types; other extensions
+// fall back to mime.TypeByExtension.
+var contentTypes = map[string]string{
+	".srt":  "application/x-subrip",
+	".vtt":  "text/vtt; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+	".md":   "text/markdown; charset=utf-8",
+	".json": "application/json",
+	".opus": "audio/ogg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+}
+
+// S3Options configures an S3Uploader.
+type S3Options struct {
+	// Endpoint is the S3 API endpoint, e.g. https://s3.eu-west-1.amazonaws.com,
+	// https://<account>.r2.cloudflarestorage.com or http://localhost:9000 for MinIO.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Prefix is prepended to every object key, e.g. "transcripts/".
+	Prefix string
+	// PublicURLBase is the public address of the bucket (a CDN or r2.dev domain). Uploaded objects are
+	// reported as PublicURLBase + "/" + key; without it the object's API URL is returned.
+	PublicURLBase string
+	// PathStyle addresses the bucket as Endpoint/bucket/key instead of bucket.host/key. MinIO needs it.
+	PathStyle bool
+}
+
+// S3Uploader implements the Uploader interface for S3-compatible object storage using
+// SigV4-signed PUT requests.
+type S3Uploader struct {
+	opts       S3Options
+	httpClient HTTPClient
+	now        func() time.Time
+	// Gzip stores objects gzip-compressed with Content-Encoding: gzip, which HTTP clients
+	// decompress transparently.
+	Gzip bool
+}
+
+// NewS3Uploader creates a new S3Uploader.
+func NewS3Uploader(opts S3Options, client HTTPClient) *S3Uploader {
+	if client == nil {
+		client = &http.Client{}
+	}
+	if opts.Region == "" {
+		opts.Region = DEFAULT_S3_REGION
+	}
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+	opts.PublicURLBase = strings.TrimSuffix(opts.PublicURLBase, "/")
+	return &S3Uploader{opts: opts, httpClient: client, now: time.Now}
+}
+
+// Validate checks that the options describe a reachable bucket.
+func (o S3Options) Validate() error {
+	if o.Bucket == "" {
+		return fmt.Errorf("S3 bucket not set")
+	}
+	if o.AccessKeyID == "" || o.SecretAccessKey == "" {
+		return fmt.Errorf("S3 access key ID and secret access key must both be set")
+	}
+	u, err := url.Parse(o.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid S3 endpoint %q", o.Endpoint)
+	}
+	return nil
+}
+
+// Upload stores content under the configured prefix, replacing any existing object.
+// The result's Pathname is the object key.
+func (s *S3Uploader) Upload(ctx context.Context, content string, filename string) (*UploadResult, error) {
+	return s.put(ctx, strings.NewReader(content), int64(len(content)), sha256Hex([]byte(content)), filename)
+}
+
+// UploadReader streams size bytes from r into the object for filename; pass a negative size when it
+// is unknown. The payload is sent unsigned (UNSIGNED-PAYLOAD) so it does not have to be read twice.
+// S3 requires a Content-Length, so bodies of unknown size and gzip-compressed bodies are first
+// spooled to a temporary file rather than buffered in memory.
+func (s *S3Uploader) UploadReader(ctx context.Context, r io.Reader, size int64, filename string) (*UploadResult, error) {
+	return s.put(ctx, r, size, UNSIGNED_PAYLOAD, filename)
+}
+
+// put sends a signed PUT request for the object.
+func (s *S3Uploader) put(ctx context.Context, body io.Reader, size int64, payloadHash string, filename string) (*UploadResult, error) {
+	key, err := cleanStoragePath(s.opts.Prefix + filename)
+	if err != nil {
+		return nil, err
+	}
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// contentLength is the size of the request body, size the size of the artifact itself.
+	contentLength := size
+	if s.Gzip || size < 0 {
+		content := &countingReader{r: body}
+		spooled, err := spool(content, s.Gzip)
+		if err != nil {
+			return nil, err
+		}
+		defer spooled.Remove()
+		body, contentLength, payloadHash = spooled, spooled.Size, UNSIGNED_PAYLOAD
+		size = content.n
+	}
+	if contentLength == 0 {
+		body = http.NoBody
+	}
+
+	contentType := contentTypeFor(filename)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = contentLength
+	req.Header.Set("Content-Type", contentType)
+	if s.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	signV4(req, payloadHash, s.opts, s.now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, &RequestError{Op: "failed to send request", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+	io.Copy(io.Discard, resp.Body)
+
+	return &UploadResult{
+		URL:         s.publicURL(key, objectURL),
+		Pathname:    key,
+		Size:        size,
+		ContentType: contentType,
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}, nil
+}
+
+// objectURL returns the API URL of key, in path or virtual-hosted style.
+func (s *S3Uploader) objectURL(key string) (string, error) {
+	bucketURL, err := s.bucketURL()
+	if err != nil {
+		return "", err
+	}
+	return bucketURL + "/" + escapeKey(key), nil
+}
+
+// bucketURL returns the API URL of the bucket, without a trailing slash.
+func (s *S3Uploader) bucketURL() (string, error) {
+	u, err := url.Parse(s.opts.Endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid S3 endpoint %q: %w", s.opts.Endpoint, err)
+	}
+	if s.opts.PathStyle {
+		return s.opts.Endpoint + "/" + escapeKey(s.opts.Bucket), nil
+	}
+	u.Host = s.opts.Bucket + "." + u.Host
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// publicURL returns the URL reported for key: below PublicURLBase when set, else the API URL.
+func (s *S3Uploader) publicURL(key, objectURL string) string {
+	if s.opts.PublicURLBase != "" {
+		return s.opts.PublicURLBase + "/" + escapeKey(key)
+	}
+	return objectURL
+}
+
+// listBucketResult is the part of the ListObjectsV2 response we use.
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// List returns the objects whose key starts with the configured prefix followed by prefix,
+// following ListObjectsV2 continuation tokens. Pathname is the full object key.
+func (s *S3Uploader) List(ctx context.Context, prefix string) ([]StoredObject, error) {
+	bucketURL, err := s.bucketURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []StoredObject
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.opts.Prefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		// The query is encoded exactly as it is signed; url.Values.Encode would turn spaces into '+'.
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, bucketURL+"?"+canonicalQuery(query), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		signV4(req, sha256Hex(nil), s.opts, s.now())
+
+		var page listBucketResult
+		if err := s.do(req, http.StatusOK, &page); err != nil {
+			return nil, err
+		}
+		for _, c := range page.Contents {
+			objectURL := bucketURL + "/" + escapeKey(c.Key)
+			objects = append(objects, StoredObject{
+				Pathname:     c.Key,
+				URL:          s.publicURL(c.Key, objectURL),
+				Size:         c.Size,
+				LastModified: c.LastModified,
+			})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Delete removes the object with key obj.Pathname. S3 reports success for missing keys.
+func (s *S3Uploader) Delete(ctx context.Context, obj StoredObject) error {
+	objectURL, err := s.objectURL(obj.Pathname)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objectURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	signV4(req, sha256Hex(nil), s.opts, s.now())
+	return s.do(req, http.StatusNoContent, nil)
+}
+
+// do sends req and checks for the wanted status (200 is always accepted). When out is not nil,
+// the XML response body is decoded into it.
+func (s *S3Uploader) do(req *http.Request, want int, out any) error {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return &RequestError{Op: "failed to send request", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &RequestError{Op: "failed to read response body", Err: err}
+	}
+	if err := xml.Unmarshal(respBody, out); err != nil {
+		return &ResponseError{Body: string(respBody), Err: err}
+	}
+	return nil
+}
+
+// contentTypeFor returns the content type stored with filename.
+func contentTypeFor(filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == "" {
+		return DEFAULT_CONTENT_TYPE
+	}
+	if contentType, ok := contentTypes[ext]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// signV4 adds the x-amz-date, x-amz-content-sha256 and Authorization headers for an AWS Signature
+// Version 4 request. The host and every header already set on req are signed.
+func signV4(req *http.Request, payloadHash string, opts S3Options, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + opts.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+opts.SecretAccessKey), date)
+	key = hmacSHA256(key, opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 requires.
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// escapeKey percent-encodes each segment of an object key.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes everything except the unreserved characters of RFC 3986.
+func uriEncode(s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,72 @@
+package uploader
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SPOOL_FILE_PREFIX names the temporary files used to measure bodies whose length is unknown.
+const SPOOL_FILE_PREFIX = "yt-transcribe-upload-"
+
+// spoolFile is a temporary copy of an upload body. Remove deletes it.
+type spoolFile struct {
+	*os.File
+	Size int64
+}
+
+// spool copies r into a temporary file, gzip-compressing it when compress is set, so that bodies
+// of unknown length can be sent with a Content-Length without holding them in memory.
+func spool(r io.Reader, compress bool) (*spoolFile, error) {
+	f, err := os.CreateTemp("", SPOOL_FILE_PREFIX+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	s := &spoolFile{File: f}
+
+	if err := copyMaybeGzip(f, r, compress); err != nil {
+		s.Remove()
+		return nil, fmt.Errorf("failed to spool upload body: %w", err)
+	}
+	if s.Size, err = f.Seek(0, io.SeekCurrent); err != nil {
+		s.Remove()
+		return nil, fmt.Errorf("failed to measure spool file: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		s.Remove()
+		return nil, fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+	return s, nil
+}
+
+// Remove closes and deletes the spool file.
+func (s *spoolFile) Remove() {
+	s.Close()
+	os.Remove(s.Name())
+}
+
+// copyMaybeGzip copies r to w, gzip-compressing the stream when compress is set.
+func copyMaybeGzip(w io.Writer, r io.Reader, compress bool) error {
+	if !compress {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	if _, err := io.Copy(zw, r); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,44 @@
+package uploader
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+var (
+	_ Uploader = (*VercelBlobUploader)(nil)
+	_ Uploader = (*S3Uploader)(nil)
+	_ Uploader = (*FilesystemUploader)(nil)
+
+	_ ObjectStore = (*VercelBlobStore)(nil)
+	_ ObjectStore = (*S3Uploader)(nil)
+	_ ObjectStore = (*FilesystemUploader)(nil)
+)
+
+func TestSpool_CompressesAndRewinds(t *testing.T) {
+	content := strings.Repeat("abc", 1000)
+	spooled, err := spool(strings.NewReader(content), true)
+	if err != nil {
+		t.Fatalf("spool failed: %v", err)
+	}
+	defer spooled.Remove()
+
+	if spooled.Size <= 0 || spooled.Size >= int64(len(content)) {
+		t.Errorf("expected a compressed size below %d, got %d", len(content), spooled.Size)
+	}
+	zr, err := gzip.NewReader(spooled)
+	if err != nil {
+		t.Fatalf("spool file is not gzip: %v", err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != content {
+		t.Errorf("spooled content does not round-trip")
+	}
+
+	spooled.Remove()
+	if _, err := os.Stat(spooled.Name()); !os.IsNotExist(err) {
+		t.Errorf("expected the spool file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package uploader
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Uploader stores artifacts; every uploader in this package implements it. size is the number of
+// bytes r yields, or negative when unknown.
+type Uploader interface {
+	Upload(ctx context.Context, content string, filename string) (*UploadResult, error)
+	UploadReader(ctx context.Context, r io.Reader, size int64, filename string) (*UploadResult, error)
+}
+
+// UploadResult describes a stored artifact. URL is always set; ETag is empty when the backend has none.
+// Size is the uncompressed size of the artifact. It has the fields of src.UploadResult.
+type UploadResult struct {
+	URL         string
+	Pathname    string
+	Size        int64
+	ContentType string
+	ETag        string
+}
+
+// ObjectStore lists and deletes what was uploaded to a storage backend.
+type ObjectStore interface {
+	// List returns every object whose pathname starts with prefix.
+	List(ctx context.Context, prefix string) ([]StoredObject, error)
+	// Delete removes obj. Deleting an object that no longer exists is not an error.
+	Delete(ctx context.Context, obj StoredObject) error
+}
+
+// StoredObject is an object listed by an ObjectStore. It has the fields of src.StoredObject.
+type StoredObject struct {
+	Pathname     string
+	URL          string
+	Size         int64
+	LastModified time.Time
+}
@@ -3,13 +3,25 @@ package uploader
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
+// blobResponse is the JSON body the Blob API answers a successful upload with.
+type blobResponse struct {
+	URL         string `json:"url"`
+	Pathname    string `json:"pathname"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	ETag        string `json:"etag"`
+}
+
 // HTTPClient interface for mocking purposes
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -35,49 +47,102 @@ func NewVercelBlobUploader(apiURL, apiToken string, client HTTPClient) *VercelBl
 }
 
 // Upload uploads the given content to Vercel Blob storage.
-func (v *VercelBlobUploader) Upload(ctx context.Context, content string, filename string) (string, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("file", filename)
-	if err != nil {
-		return "", fmt.Errorf("failed to create form file: %w", err)
-	}
+func (v *VercelBlobUploader) Upload(ctx context.Context, content string, filename string) (*UploadResult, error) {
+	return v.UploadReader(ctx, strings.NewReader(content), int64(len(content)), filename)
+}
 
-	if _, err := io.Copy(part, bytes.NewReader([]byte(content))); err != nil {
-		return "", fmt.Errorf("failed to copy content to form file: %w", err)
+// UploadReader streams size bytes from r to Vercel Blob storage; pass a negative size when it is
+// unknown. The multipart body is assembled around r instead of being buffered, so memory use does
+// not depend on the artifact size. The request has a Content-Length unless the size is unknown, in
+// which case it is sent chunked.
+func (v *VercelBlobUploader) UploadReader(ctx context.Context, r io.Reader, size int64, filename string) (*UploadResult, error) {
+	var head bytes.Buffer
+	writer := multipart.NewWriter(&head)
+	if _, err := writer.CreateFormFile("file", filename); err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
 	}
-
+	prefix := bytes.Clone(head.Bytes())
+	head.Reset()
 	writer.Close()
+	suffix := head.Bytes()
 
 	// URL-encode the filename to safely include it in the query string.
 	// allowOverwrite=true is required when reprocessing existing blobs.
 	encodedFilename := url.QueryEscape(filename)
 	uploadURL := fmt.Sprintf("%s?blob_path=%s&allow_overwrite=true", v.apiURL, encodedFilename)
 
+	content := &countingReader{r: r}
+	multipartBody := io.MultiReader(bytes.NewReader(prefix), content, bytes.NewReader(suffix))
+	var body io.Reader = multipartBody
+	contentLength := int64(len(prefix)) + size + int64(len(suffix))
+	wait := func() {}
+	if size < 0 {
+		pr, pw := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := io.Copy(pw, multipartBody)
+			pw.CloseWithError(err)
+		}()
+		// Stop the writer if the request ends early; waiting for it makes content.n safe to read.
+		wait = func() {
+			pr.Close()
+			<-done
+		}
+		defer wait()
+		body, contentLength = pr, -1
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.ContentLength = contentLength
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+v.apiToken)
 
 	resp, err := v.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, &RequestError{Op: "failed to send request", Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("upload failed with status code %d: %s", resp.StatusCode, string(respBody))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, &RequestError{Op: "failed to read response body", Err: err}
+	}
+
+	if size < 0 {
+		wait()
+		size = content.n
 	}
+	return parseBlobResponse(respBody, size)
+}
 
-	return string(respBody), nil
+// parseBlobResponse converts the Blob API response into an UploadResult. A missing size is
+// filled in from the uploaded content.
+func parseBlobResponse(body []byte, size int64) (*UploadResult, error) {
+	var blob blobResponse
+	if err := json.Unmarshal(body, &blob); err != nil {
+		return nil, &ResponseError{Body: string(body), Err: err}
+	}
+	if blob.URL == "" {
+		return nil, &ResponseError{Body: string(body), Err: errors.New("missing url")}
+	}
+	if blob.Size == 0 {
+		blob.Size = size
+	}
+	return &UploadResult{
+		URL:         blob.URL,
+		Pathname:    blob.Pathname,
+		Size:        blob.Size,
+		ContentType: blob.ContentType,
+		ETag:        blob.ETag,
+	}, nil
 }
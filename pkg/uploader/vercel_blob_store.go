@@ -0,0 +1,115 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DEFAULT_VERCEL_BLOB_STORE_URL is the Vercel Blob REST API used to list and delete blobs.
+const DEFAULT_VERCEL_BLOB_STORE_URL = "https://blob.vercel-storage.com"
+
+// VercelBlobStore lists and deletes blobs through the Vercel Blob REST API. Uploads go through the
+// Blob upload API used by VercelBlobUploader, which does not offer listing, so the store needs the
+// store's own read-write token.
+type VercelBlobStore struct {
+	storeURL   string
+	token      string
+	httpClient HTTPClient
+}
+
+// NewVercelBlobStore creates a VercelBlobStore. An empty storeURL selects DEFAULT_VERCEL_BLOB_STORE_URL.
+func NewVercelBlobStore(storeURL, token string, client HTTPClient) *VercelBlobStore {
+	if client == nil {
+		client = &http.Client{}
+	}
+	if storeURL == "" {
+		storeURL = DEFAULT_VERCEL_BLOB_STORE_URL
+	}
+	return &VercelBlobStore{storeURL: strings.TrimSuffix(storeURL, "/"), token: token, httpClient: client}
+}
+
+// listBlobsResponse is one page of the list API.
+type listBlobsResponse struct {
+	Blobs []struct {
+		URL        string    `json:"url"`
+		Pathname   string    `json:"pathname"`
+		Size       int64     `json:"size"`
+		UploadedAt time.Time `json:"uploadedAt"`
+	} `json:"blobs"`
+	Cursor  string `json:"cursor"`
+	HasMore bool   `json:"hasMore"`
+}
+
+// List returns the blobs whose pathname starts with prefix, following the list cursor.
+func (v *VercelBlobStore) List(ctx context.Context, prefix string) ([]StoredObject, error) {
+	var objects []StoredObject
+	cursor := ""
+	for {
+		query := url.Values{"prefix": {prefix}, "limit": {"1000"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.storeURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		var page listBlobsResponse
+		if err := v.do(req, &page); err != nil {
+			return nil, err
+		}
+		for _, b := range page.Blobs {
+			objects = append(objects, StoredObject{Pathname: b.Pathname, URL: b.URL, Size: b.Size, LastModified: b.UploadedAt})
+		}
+		if !page.HasMore || page.Cursor == "" {
+			return objects, nil
+		}
+		cursor = page.Cursor
+	}
+}
+
+// Delete removes the blob at obj.URL.
+func (v *VercelBlobStore) Delete(ctx context.Context, obj StoredObject) error {
+	body, err := json.Marshal(map[string][]string{"urls": {obj.URL}})
+	if err != nil {
+		return fmt.Errorf("failed to encode delete request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.storeURL+"/delete", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return v.do(req, nil)
+}
+
+// do sends an authorised request and decodes the JSON response into out when it is not nil.
+func (v *VercelBlobStore) do(req *http.Request, out any) error {
+	req.Header.Set("Authorization", "Bearer "+v.token)
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return &RequestError{Op: "failed to send request", Err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &RequestError{Op: "failed to read response body", Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return &ResponseError{Body: string(respBody), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package uploader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVercelBlobStore_ListFollowsCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer rw-token" {
+			t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("prefix") != "yt-transcribe/" {
+			t.Errorf("unexpected prefix %q", r.URL.Query().Get("prefix"))
+		}
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			fmt.Fprint(w, `{"blobs":[{"url":"https://x.public.blob.vercel-storage.com/yt-transcribe/youtube/a","pathname":"yt-transcribe/youtube/a","size":3,"uploadedAt":"2025-01-20T09:14:02Z"}],"cursor":"next","hasMore":true}`)
+		case "next":
+			fmt.Fprint(w, `{"blobs":[{"url":"https://x.public.blob.vercel-storage.com/yt-transcribe/youtube/b","pathname":"yt-transcribe/youtube/b","size":4,"uploadedAt":"2025-01-21T09:14:02Z"}],"hasMore":false}`)
+		default:
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+	}))
+	defer server.Close()
+
+	objects, err := NewVercelBlobStore(server.URL, "rw-token", nil).List(context.Background(), "yt-transcribe/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 || objects[1].Pathname != "yt-transcribe/youtube/b" || objects[1].Size != 4 || objects[1].LastModified.IsZero() {
+		t.Errorf("unexpected objects %+v", objects)
+	}
+}
+
+func TestVercelBlobStore_Delete(t *testing.T) {
+	const blobURL = "https://x.public.blob.vercel-storage.com/yt-transcribe/youtube/a"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/delete" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			URLs []string `json:"urls"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.URLs) != 1 || body.URLs[0] != blobURL {
+			t.Errorf("unexpected delete body %+v (%v)", body, err)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewVercelBlobStore(server.URL, "rw-token", nil).Delete(context.Background(), StoredObject{URL: blobURL}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+}
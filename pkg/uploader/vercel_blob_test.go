@@ -2,6 +2,7 @@ package uploader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -44,7 +45,7 @@ func TestNewVercelBlobUploader(t *testing.T) {
 
 // TestUpload_Success tests a successful upload scenario.
 func TestUpload_Success(t *testing.T) {
-	expectedResponse := "upload successful"
+	expectedResponse := `{"url":"https://blob.example.com/test.txt","pathname":"test.txt","contentType":"text/plain","size":12}`
 	filename := "test.txt"
 	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
@@ -73,13 +74,32 @@ func TestUpload_Success(t *testing.T) {
 	uploader := NewVercelBlobUploader(testServer.URL, "test-token", nil)
 	content := "test content"
 
-	response, err := uploader.Upload(context.Background(), content, filename)
+	result, err := uploader.Upload(context.Background(), content, filename)
 
 	if err != nil {
 		t.Fatalf("Upload failed unexpectedly: %v", err)
 	}
-	if response != expectedResponse {
-		t.Errorf("Expected response '%s', got '%s'", expectedResponse, response)
+	if result.URL != "https://blob.example.com/test.txt" || result.Pathname != filename || result.ContentType != "text/plain" || result.Size != 12 {
+		t.Errorf("Unexpected upload result %+v", result)
+	}
+}
+
+// TestUpload_UnparseableResponse tests that a 200 response without a blob URL is a permanent error.
+func TestUpload_UnparseableResponse(t *testing.T) {
+	for _, body := range []string{"upload successful", `{"pathname":"test.txt"}`} {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		}))
+		result, err := NewVercelBlobUploader(testServer.URL, "test-token", nil).Upload(context.Background(), "content", "test.txt")
+		testServer.Close()
+
+		var responseErr *ResponseError
+		if !errors.As(err, &responseErr) {
+			t.Fatalf("%s: expected a *ResponseError, got result %+v and error %v", body, result, err)
+		}
+		if responseErr.Retryable() {
+			t.Errorf("%s: expected the error to be permanent", body)
+		}
 	}
 }
 
@@ -195,7 +215,7 @@ func TestUpload_CustomHTTPClient(t *testing.T) {
 			}
 			return &http.Response{
 				StatusCode: http.StatusOK,
-				Body:       io.NopCloser(strings.NewReader("mocked response")),
+				Body:       io.NopCloser(strings.NewReader(`{"url":"https://blob.example.com/test.txt"}`)),
 			}, nil
 		},
 	}
@@ -203,12 +223,122 @@ func TestUpload_CustomHTTPClient(t *testing.T) {
 	uploader := NewVercelBlobUploader("https://example.com/api", "test-token", mockClient)
 	content := "test content"
 
-	response, err := uploader.Upload(context.Background(), content, filename)
+	result, err := uploader.Upload(context.Background(), content, filename)
 
 	if err != nil {
 		t.Fatalf("Upload failed unexpectedly: %v", err)
 	}
-	if response != "mocked response" {
-		t.Errorf("Expected mocked response, got %s", response)
+	if result.URL != "https://blob.example.com/test.txt" {
+		t.Errorf("Expected the mocked blob URL, got %s", result.URL)
+	}
+	if result.Size != int64(len(content)) {
+		t.Errorf("Expected the size to default to the content length, got %d", result.Size)
+	}
+}
+
+func TestUpload_ServerErrorIsRetryable(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer testServer.Close()
+
+	_, err := NewVercelBlobUploader(testServer.URL, "test-token", nil).Upload(context.Background(), "content", "test.txt")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected a *StatusError, got %v", err)
 	}
+	if !statusErr.Retryable() {
+		t.Errorf("expected status %d to be retryable", statusErr.StatusCode)
+	}
+}
+
+func TestUpload_ClientErrorIsPermanent(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer testServer.Close()
+
+	_, err := NewVercelBlobUploader(testServer.URL, "test-token", nil).Upload(context.Background(), "content", "test.txt")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected a *StatusError, got %v", err)
+	}
+	if statusErr.Retryable() {
+		t.Errorf("expected status %d to be permanent", statusErr.StatusCode)
+	}
+}
+
+// TestUploadReader_StreamsKnownSize tests that a large artifact is sent with an exact Content-Length.
+func TestUploadReader_StreamsKnownSize(t *testing.T) {
+	const size = 8 << 20
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TransferEncoding) != 0 {
+			t.Errorf("expected a Content-Length, got Transfer-Encoding %v", r.TransferEncoding)
+		}
+		n := readFormFile(t, r)
+		if n != size {
+			t.Errorf("expected %d bytes in the form file, got %d", size, n)
+		}
+		fmt.Fprint(w, `{"url":"https://blob.example.com/audio.opus"}`)
+	}))
+	defer testServer.Close()
+
+	body := io.LimitReader(zeroReader{}, size)
+	result, err := NewVercelBlobUploader(testServer.URL, "test-token", nil).UploadReader(context.Background(), body, size, "audio.opus")
+	if err != nil {
+		t.Fatalf("UploadReader failed: %v", err)
+	}
+	if result.Size != size {
+		t.Errorf("expected size %d, got %d", size, result.Size)
+	}
+}
+
+// TestUploadReader_StreamsUnknownSize tests that a body of unknown size is sent chunked.
+func TestUploadReader_StreamsUnknownSize(t *testing.T) {
+	content := strings.Repeat("hello world\n", 1000)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TransferEncoding) == 0 || r.TransferEncoding[0] != "chunked" {
+			t.Errorf("expected a chunked body, got Transfer-Encoding %v", r.TransferEncoding)
+		}
+		if n := readFormFile(t, r); n != int64(len(content)) {
+			t.Errorf("expected %d bytes in the form file, got %d", len(content), n)
+		}
+		fmt.Fprint(w, `{"url":"https://blob.example.com/words.json"}`)
+	}))
+	defer testServer.Close()
+
+	result, err := NewVercelBlobUploader(testServer.URL, "test-token", nil).UploadReader(context.Background(), strings.NewReader(content), -1, "words.json")
+	if err != nil {
+		t.Fatalf("UploadReader failed: %v", err)
+	}
+	if result.Size != int64(len(content)) {
+		t.Errorf("expected the streamed size %d, got %d", len(content), result.Size)
+	}
+}
+
+// readFormFile returns the length of the "file" part of a multipart request.
+func readFormFile(t *testing.T, r *http.Request) int64 {
+	t.Helper()
+	mr, err := r.MultipartReader()
+	if err != nil {
+		t.Fatalf("expected multipart form data: %v", err)
+	}
+	part, err := mr.NextPart()
+	if err != nil || part.FormName() != "file" {
+		t.Fatalf("expected a file part, got %v", err)
+	}
+	n, err := io.Copy(io.Discard, part)
+	if err != nil {
+		t.Fatalf("failed to read the file part: %v", err)
+	}
+	return n
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
 }
@@ -0,0 +1,120 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ARCHIVE_AUDIO_EXT is appended to the transcript path to name the archived audio.
+const ARCHIVE_AUDIO_EXT = ".opus"
+
+// AudioEncoder compresses downloaded audio for archival.
+type AudioEncoder interface {
+	Encode(ctx context.Context, inputPath, outputPath string) error
+}
+
+type archivedAudioKey struct{}
+
+// WithArchivedAudio returns a context telling Run that the audio of the job was archived at audioURL.
+// Run then transcribes the archive instead of downloading the video again, skipping the pre-flight
+// checks, and falls back to the video URL only when the archive cannot be downloaded.
+func WithArchivedAudio(ctx context.Context, audioURL string) context.Context {
+	return context.WithValue(ctx, archivedAudioKey{}, audioURL)
+}
+
+func archivedAudioFrom(ctx context.Context) string {
+	audioURL, _ := ctx.Value(archivedAudioKey{}).(string)
+	return audioURL
+}
+
+// archivedVideoID returns the video ID encoded in the name of an archive, {videoID}.opus.
+func archivedVideoID(audioURL string) string {
+	u, err := url.Parse(audioURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if !strings.HasSuffix(name, ARCHIVE_AUDIO_EXT) {
+		return ""
+	}
+	return strings.TrimSuffix(name, ARCHIVE_AUDIO_EXT)
+}
+
+// download fetches the audio of videoURL into dir, preferring the archived audio in ctx.
+// It returns the archive URL when the archive was used.
+func (s *TranscriptionServiceImpl) download(ctx context.Context, videoURL, dir string) (audioFilePath, videoID, archiveURL string, err error) {
+	if archiveURL = archivedAudioFrom(ctx); archiveURL != "" {
+		fmt.Printf("Downloading archived audio: %s\n", archiveURL)
+		err = s.Retry.Download.Do(ctx, STAGE_DOWNLOAD, func(ctx context.Context) error {
+			var err error
+			audioFilePath, _, err = s.Downloader.DownloadAudio(ctx, archiveURL, dir)
+			return err
+		})
+		if err == nil {
+			// The downloader derives its ID from the archive URL; keep the original one so the
+			// transcript is written to the same path as before.
+			if videoID = archivedVideoID(archiveURL); videoID != "" {
+				return audioFilePath, videoID, archiveURL, nil
+			}
+			err = fmt.Errorf("cannot determine the video ID from %s", archiveURL)
+		}
+		if ctx.Err() != nil {
+			return "", "", "", fmt.Errorf("error downloading archived audio: %w", err)
+		}
+		fmt.Printf("Warning: archived audio unavailable (%v); downloading %s instead\n", err, videoURL)
+	}
+
+	fmt.Println("Downloading audio...")
+	err = s.Retry.Download.Do(ctx, STAGE_DOWNLOAD, func(ctx context.Context) error {
+		var err error
+		audioFilePath, videoID, err = s.Downloader.DownloadAudio(ctx, videoURL, dir)
+		return err
+	})
+	if err != nil {
+		return "", "", "", fmt.Errorf("error downloading audio: %w", err)
+	}
+	return audioFilePath, videoID, "", nil
+}
+
+// archiveAudio compresses audioFilePath with s.AudioEncoder and uploads it as uploadPath + ARCHIVE_AUDIO_EXT.
+// Archival is best effort: failures are logged and reported as an empty URL, so they never fail the job.
+func (s *TranscriptionServiceImpl) archiveAudio(ctx context.Context, audioFilePath, uploadPath string) string {
+	streamUploader, ok := s.Uploader.(StreamUploader)
+	if !ok {
+		fmt.Println("Warning: the uploader cannot stream files; audio is not archived")
+		return ""
+	}
+
+	fmt.Println("Archiving audio...")
+	archivePath := strings.TrimSuffix(audioFilePath, filepath.Ext(audioFilePath)) + ARCHIVE_AUDIO_EXT
+	if err := s.AudioEncoder.Encode(ctx, audioFilePath, archivePath); err != nil {
+		fmt.Printf("Warning: could not encode audio for archival: %v\n", err)
+		return ""
+	}
+
+	var upload *UploadResult
+	err := s.Retry.Upload.Do(ctx, STAGE_UPLOAD, func(ctx context.Context) error {
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		upload, err = streamUploader.UploadReader(ctx, f, info.Size(), uploadPath+ARCHIVE_AUDIO_EXT)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("Warning: could not upload archived audio: %v\n", err)
+		return ""
+	}
+	fmt.Printf("Audio archived: %s\n", upload.URL)
+	return upload.URL
+}
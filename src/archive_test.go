@@ -0,0 +1,126 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+type copyEncoder struct{ calls int }
+
+func (e *copyEncoder) Encode(ctx context.Context, inputPath, outputPath string) error {
+	e.calls++
+	return os.WriteFile(outputPath, []byte("opus audio"), 0644)
+}
+
+// streamingUploader records the files it receives; it implements StreamUploader.
+type streamingUploader struct {
+	countingUploader
+	streamed map[string]string
+}
+
+func (u *streamingUploader) UploadReader(ctx context.Context, r io.Reader, size int64, filename string) (*UploadResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if u.streamed == nil {
+		u.streamed = map[string]string{}
+	}
+	u.streamed[filename] = string(data)
+	return &UploadResult{URL: "https://blob.example.com/" + filename, Pathname: filename, Size: size}, nil
+}
+
+// archiveDownloader records the URLs it downloads and fails for those in failing.
+type archiveDownloader struct {
+	urls    []string
+	failing string
+}
+
+func (d *archiveDownloader) DownloadAudio(ctx context.Context, videoURL, outputDir string) (string, string, error) {
+	d.urls = append(d.urls, videoURL)
+	if videoURL == d.failing {
+		return "", "", errors.New("404 Not Found")
+	}
+	path := outputDir + "/audio.wav"
+	if err := os.WriteFile(path, []byte("wav audio"), 0644); err != nil {
+		return "", "", err
+	}
+	if strings.HasSuffix(videoURL, ARCHIVE_AUDIO_EXT) {
+		return path, "abc-0123abcd", nil
+	}
+	return path, "abc", nil
+}
+
+func TestRun_ArchivesAudio(t *testing.T) {
+	encoder := &copyEncoder{}
+	uploader := &streamingUploader{}
+	svc := &TranscriptionServiceImpl{Downloader: &archiveDownloader{}, Transcriber: stubTranscriber{"text"}, Uploader: uploader, Retry: DefaultRetryPolicies(), AudioEncoder: encoder}
+
+	result, err := svc.Run(context.Background(), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := uploader.streamed["yt-transcribe/youtube/abc.opus"]; got != "opus audio" {
+		t.Errorf("expected the encoded audio to be uploaded, got %v", uploader.streamed)
+	}
+	if result.AudioURL != "https://blob.example.com/yt-transcribe/youtube/abc.opus" {
+		t.Errorf("unexpected AudioURL %q", result.AudioURL)
+	}
+}
+
+func TestRun_ArchiveFailureDoesNotFailJob(t *testing.T) {
+	// countingUploader cannot stream, so the audio cannot be archived.
+	uploader := &countingUploader{}
+	svc := &TranscriptionServiceImpl{Downloader: &archiveDownloader{}, Transcriber: stubTranscriber{"text"}, Uploader: uploader, Retry: DefaultRetryPolicies(), AudioEncoder: &copyEncoder{}}
+
+	result, err := svc.Run(context.Background(), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if uploader.uploads != 1 || result.AudioURL != "" {
+		t.Errorf("expected the transcript without archive, got %d uploads and %+v", uploader.uploads, result)
+	}
+}
+
+func TestRun_TranscribesArchivedAudio(t *testing.T) {
+	const archiveURL = "https://blob.example.com/yt-transcribe/youtube/abc.opus"
+	downloader := &archiveDownloader{}
+	encoder := &copyEncoder{}
+	uploader := &streamingUploader{}
+	svc := &TranscriptionServiceImpl{Downloader: downloader, Transcriber: stubTranscriber{"text"}, Uploader: uploader, Retry: DefaultRetryPolicies(), AudioEncoder: encoder}
+
+	result, err := svc.Run(WithArchivedAudio(context.Background(), archiveURL), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(downloader.urls) != 1 || downloader.urls[0] != archiveURL {
+		t.Errorf("expected only the archive to be downloaded, got %v", downloader.urls)
+	}
+	if encoder.calls != 0 || len(uploader.streamed) != 0 {
+		t.Errorf("archived audio must not be archived again")
+	}
+	if result.VideoID != "abc" || result.URL != "https://blob.example.com/yt-transcribe/youtube/abc" || result.AudioURL != archiveURL {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestRun_FallsBackWhenArchiveIsMissing(t *testing.T) {
+	const archiveURL = "https://blob.example.com/yt-transcribe/youtube/abc.opus"
+	downloader := &archiveDownloader{failing: archiveURL}
+	svc := &TranscriptionServiceImpl{Downloader: downloader, Transcriber: stubTranscriber{"text"}, Uploader: &streamingUploader{}, Retry: DefaultRetryPolicies(), AudioEncoder: &copyEncoder{}}
+
+	result, err := svc.Run(WithArchivedAudio(context.Background(), archiveURL), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(downloader.urls) != 2 || downloader.urls[1] != "https://www.youtube.com/watch?v=abc" {
+		t.Errorf("expected a fallback to the video URL, got %v", downloader.urls)
+	}
+	if result.AudioURL != archiveURL {
+		t.Errorf("expected the audio to be archived again, got %q", result.AudioURL)
+	}
+}
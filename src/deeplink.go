@@ -0,0 +1,16 @@
+package src
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// DeepLink returns the URL that opens a video at start. Only YouTube supports a start time; other
+// platforms get videoURL unchanged.
+func DeepLink(platform, videoID, videoURL string, start time.Duration) string {
+	if platform != PLATFORM_YOUTUBE || videoID == "" {
+		return videoURL
+	}
+	return fmt.Sprintf("https://www.youtube.com/watch?v=%s&t=%ds", url.QueryEscape(videoID), int(start.Seconds()))
+}
@@ -0,0 +1,15 @@
+package src
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeepLink(t *testing.T) {
+	if link := DeepLink(PLATFORM_YOUTUBE, "dQw4w9WgXcQ", "https://youtu.be/dQw4w9WgXcQ", 123500*time.Millisecond); link != "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=123s" {
+		t.Errorf("unexpected YouTube link %s", link)
+	}
+	if link := DeepLink(PLATFORM_INSTAGRAM, "C1a2b3D4e5F", "https://www.instagram.com/reel/C1a2b3D4e5F/", time.Minute); link != "https://www.instagram.com/reel/C1a2b3D4e5F/" {
+		t.Errorf("want the video URL, got %s", link)
+	}
+}
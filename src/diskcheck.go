@@ -0,0 +1,94 @@
+package src
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// WAV_BYTES_PER_SECOND is the size of one second of the 16 kHz mono 16-bit WAV fed to whisper.cpp.
+const WAV_BYTES_PER_SECOND = 16000 * 2
+
+// InsufficientSpaceError is returned when a filesystem does not have room for a job.
+// It is retryable: the job can run once space has been freed.
+type InsufficientSpaceError struct {
+	Path     string
+	Required uint64
+	Free     uint64
+}
+
+// Error implements the error interface.
+func (e *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("insufficient disk space in %s: %d MB required, %d MB free", e.Path, e.Required>>20, e.Free>>20)
+}
+
+// Retryable always reports true.
+func (e *InsufficientSpaceError) Retryable() bool {
+	return true
+}
+
+// EstimateJobSpace returns the bytes a job needs in its working directory: the downloaded stream
+// plus the converted WAV. It returns 0 when meta is nil.
+func EstimateJobSpace(meta *MediaMetadata) uint64 {
+	if meta == nil {
+		return 0
+	}
+	return uint64(max(meta.EstimatedSize, 0)) + uint64(max(meta.Duration.Seconds(), 0)*WAV_BYTES_PER_SECOND)
+}
+
+// diskReservations holds the estimated space of the jobs in flight, by working directory, so
+// concurrent jobs cannot all pass the disk space check against the same free bytes.
+type diskReservations struct {
+	mu     sync.Mutex
+	byPath map[string]uint64
+}
+
+// reserveDiskSpace verifies that outputDir has room for the job estimated from meta on top of the
+// space reserved by the jobs already in flight, and that both outputDir and os.TempDir (used by
+// whisper-cli) keep at least s.MinFreeSpace free. On success the estimate stays reserved until the
+// returned release function is called. It does nothing when s.FreeSpace is nil.
+func (s *TranscriptionServiceImpl) reserveDiskSpace(outputDir string, meta *MediaMetadata) (func(), error) {
+	if s.FreeSpace == nil {
+		return func() {}, nil
+	}
+
+	s.reservations.mu.Lock()
+	defer s.reservations.mu.Unlock()
+
+	type requirement struct {
+		path  string
+		bytes uint64
+	}
+	estimate := EstimateJobSpace(meta)
+	checks := []requirement{{outputDir, estimate + s.MinFreeSpace}}
+	if tempDir := os.TempDir(); tempDir != outputDir {
+		checks = append(checks, requirement{tempDir, s.MinFreeSpace})
+	}
+
+	for _, c := range checks {
+		free, err := s.FreeSpace(c.path)
+		if err != nil {
+			return nil, fmt.Errorf("error checking free disk space: %w", err)
+		}
+		// Jobs in flight have not necessarily written their files yet
+		required := c.bytes + s.reservations.byPath[c.path]
+		if free < required {
+			return nil, &InsufficientSpaceError{Path: c.path, Required: required, Free: free}
+		}
+	}
+
+	if s.reservations.byPath == nil {
+		s.reservations.byPath = map[string]uint64{}
+	}
+	s.reservations.byPath[outputDir] += estimate
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.reservations.mu.Lock()
+			defer s.reservations.mu.Unlock()
+			if s.reservations.byPath[outputDir] -= estimate; s.reservations.byPath[outputDir] == 0 {
+				delete(s.reservations.byPath, outputDir)
+			}
+		})
+	}, nil
+}
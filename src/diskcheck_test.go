@@ -0,0 +1,96 @@
+package src
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEstimateJobSpace(t *testing.T) {
+	if got := EstimateJobSpace(nil); got != 0 {
+		t.Errorf("nil metadata: want 0, got %d", got)
+	}
+
+	meta := &MediaMetadata{Duration: time.Hour, EstimatedSize: 20 << 20}
+	want := uint64(20<<20) + 3600*WAV_BYTES_PER_SECOND
+	if got := EstimateJobSpace(meta); got != want {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	outputDir := t.TempDir()
+	meta := &MediaMetadata{Duration: time.Minute}
+	required := EstimateJobSpace(meta) + 100
+
+	cases := []struct {
+		name      string
+		free      map[string]uint64
+		wantPath  string
+		wantError bool
+	}{
+		{"enough space", map[string]uint64{outputDir: required, os.TempDir(): 100}, "", false},
+		{"output dir full", map[string]uint64{outputDir: required - 1, os.TempDir(): 100}, outputDir, true},
+		{"temp dir full", map[string]uint64{outputDir: required, os.TempDir(): 99}, os.TempDir(), true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &TranscriptionServiceImpl{
+				MinFreeSpace: 100,
+				FreeSpace:    func(path string) (uint64, error) { return tc.free[path], nil },
+			}
+
+			_, err := s.reserveDiskSpace(outputDir, meta)
+			if !tc.wantError {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var spaceErr *InsufficientSpaceError
+			if !errors.As(err, &spaceErr) {
+				t.Fatalf("expected *InsufficientSpaceError, got %v", err)
+			}
+			if spaceErr.Path != tc.wantPath {
+				t.Errorf("Path: want %q, got %q", tc.wantPath, spaceErr.Path)
+			}
+			if !IsRetryable(err) {
+				t.Error("expected insufficient space to be retryable")
+			}
+		})
+	}
+}
+
+func TestCheckDiskSpace_DisabledWithoutFreeSpaceFunc(t *testing.T) {
+	s := &TranscriptionServiceImpl{MinFreeSpace: 1 << 60}
+
+	if _, err := s.reserveDiskSpace(t.TempDir(), &MediaMetadata{Duration: time.Hour}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReserveDiskSpace_CountsJobsInFlight(t *testing.T) {
+	outputDir := t.TempDir()
+	meta := &MediaMetadata{Duration: time.Minute}
+	estimate := EstimateJobSpace(meta)
+	// Room for one job and a half; the disk does not fill up until the jobs download
+	s := &TranscriptionServiceImpl{FreeSpace: func(string) (uint64, error) { return estimate * 3 / 2, nil }}
+
+	release, err := s.reserveDiskSpace(outputDir, meta)
+	if err != nil {
+		t.Fatalf("first job: unexpected error: %v", err)
+	}
+	var spaceErr *InsufficientSpaceError
+	if _, err := s.reserveDiskSpace(outputDir, meta); !errors.As(err, &spaceErr) || spaceErr.Required != 2*estimate {
+		t.Fatalf("second job: want an InsufficientSpaceError requiring %d bytes, got %v", 2*estimate, err)
+	}
+
+	release()
+	release()
+	if _, err := s.reserveDiskSpace(outputDir, meta); err != nil {
+		t.Fatalf("after release: unexpected error: %v", err)
+	}
+}
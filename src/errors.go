@@ -0,0 +1,23 @@
+package src
+
+import "errors"
+
+// retryable is implemented by errors that know whether repeating the failed
+// operation may succeed (for example downloader.DownloadError).
+type retryable interface {
+	Retryable() bool
+}
+
+// IsRetryable reports whether err, or an error it wraps, declares itself retryable.
+// Errors that carry no classification are not considered retryable.
+func IsRetryable(err error) bool {
+	var r retryable
+	return errors.As(err, &r) && r.Retryable()
+}
+
+// IsPermanent reports whether err, or an error it wraps, declares that retrying cannot help
+// (e.g. the video is private or has been removed).
+func IsPermanent(err error) bool {
+	var r retryable
+	return errors.As(err, &r) && !r.Retryable()
+}
@@ -0,0 +1,132 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GC_MIN_AGE protects objects uploaded by jobs that have not yet written their URL to the database.
+const GC_MIN_AGE = 24 * time.Hour
+
+// StoredObject is an artifact listed by an ObjectStore. Pathname is the backend's key for it.
+type StoredObject struct {
+	Pathname     string
+	URL          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ObjectStore is implemented by storage backends that can list and delete what was uploaded to them.
+type ObjectStore interface {
+	// List returns every object whose pathname starts with prefix.
+	List(ctx context.Context, prefix string) ([]StoredObject, error)
+	// Delete removes obj. Deleting an object that no longer exists is not an error.
+	Delete(ctx context.Context, obj StoredObject) error
+}
+
+// GCOptions controls CollectGarbage.
+type GCOptions struct {
+	// Prefix limits collection to objects below it, e.g. "yt-transcribe/".
+	Prefix string
+	// MinAge keeps objects modified more recently than this, even when nothing references them.
+	MinAge time.Duration
+	// Delete removes the orphans; without it CollectGarbage only reports them.
+	Delete bool
+	// Now is the reference time for MinAge; the zero value means time.Now.
+	Now time.Time
+}
+
+// GCReport is the outcome of CollectGarbage.
+type GCReport struct {
+	// Scanned is the number of objects listed below the prefix.
+	Scanned int
+	// Orphans are the objects no database URL refers to, including any that failed to delete.
+	Orphans []StoredObject
+	// Deleted is the number of orphans removed.
+	Deleted int
+	// Errors holds one error per orphan that could not be deleted.
+	Errors []error
+}
+
+// OrphanBytes returns the total size of the orphans.
+func (r *GCReport) OrphanBytes() int64 {
+	var total int64
+	for _, obj := range r.Orphans {
+		total += obj.Size
+	}
+	return total
+}
+
+// CollectGarbage lists the objects in store below opts.Prefix and reports those that none of
+// referencedURLs point to. An object counts as referenced when its URL is in referencedURLs, or
+// when the path of a referenced URL ends with its pathname, so that objects are kept even when the
+// public URL base of a backend has changed. With opts.Delete set, orphans are deleted; deletion is
+// refused when referencedURLs is empty, because that more likely means a wrong database than an
+// empty one.
+func CollectGarbage(ctx context.Context, store ObjectStore, referencedURLs []string, opts GCOptions) (*GCReport, error) {
+	if opts.Delete && len(referencedURLs) == 0 {
+		return nil, fmt.Errorf("refusing to delete: the database references no artifacts")
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	objects, err := store.List(ctx, opts.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored objects: %w", err)
+	}
+
+	refs := newReferenceSet(referencedURLs)
+	report := &GCReport{Scanned: len(objects)}
+	for _, obj := range objects {
+		if refs.contains(obj) || now.Sub(obj.LastModified) < opts.MinAge {
+			continue
+		}
+		report.Orphans = append(report.Orphans, obj)
+	}
+
+	if !opts.Delete {
+		return report, nil
+	}
+	for _, obj := range report.Orphans {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if err := store.Delete(ctx, obj); err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf("failed to delete %s: %w", obj.Pathname, err))
+			continue
+		}
+		report.Deleted++
+	}
+	return report, nil
+}
+
+// referenceSet holds the referenced URLs and every trailing segment sequence of their paths.
+type referenceSet struct {
+	urls  map[string]bool
+	paths map[string]bool
+}
+
+func newReferenceSet(referencedURLs []string) referenceSet {
+	refs := referenceSet{urls: map[string]bool{}, paths: map[string]bool{}}
+	for _, raw := range referencedURLs {
+		refs.urls[raw] = true
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for i := range segments {
+			refs.paths[strings.Join(segments[i:], "/")] = true
+		}
+	}
+	return refs
+}
+
+func (r referenceSet) contains(obj StoredObject) bool {
+	return r.urls[obj.URL] || r.paths[strings.Trim(obj.Pathname, "/")]
+}
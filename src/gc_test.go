@@ -0,0 +1,94 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	objects   []StoredObject
+	deleted   []string
+	deleteErr error
+}
+
+func (m *memoryStore) List(ctx context.Context, prefix string) ([]StoredObject, error) {
+	return m.objects, nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, obj StoredObject) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.deleted = append(m.deleted, obj.Pathname)
+	return nil
+}
+
+var gcNow = time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func gcStore() *memoryStore {
+	old := gcNow.Add(-30 * 24 * time.Hour)
+	return &memoryStore{objects: []StoredObject{
+		{Pathname: "yt-transcribe/youtube/kept", URL: "https://blob.example.com/yt-transcribe/youtube/kept", Size: 10, LastModified: old},
+		{Pathname: "prod/yt-transcribe/youtube/moved", URL: "https://s3.example.com/b/prod/yt-transcribe/youtube/moved", Size: 20, LastModified: old},
+		{Pathname: "yt-transcribe/youtube/orphan", URL: "https://blob.example.com/yt-transcribe/youtube/orphan", Size: 30, LastModified: old},
+		{Pathname: "yt-transcribe/youtube/fresh", URL: "https://blob.example.com/yt-transcribe/youtube/fresh", Size: 40, LastModified: gcNow.Add(-time.Hour)},
+	}}
+}
+
+var gcReferences = []string{
+	"https://blob.example.com/yt-transcribe/youtube/kept",
+	// Same object, but recorded before the public URL base changed.
+	"https://cdn.example.com/prod/yt-transcribe/youtube/moved",
+}
+
+func TestCollectGarbage_DryRunReportsOrphans(t *testing.T) {
+	store := gcStore()
+	report, err := CollectGarbage(context.Background(), store, gcReferences, GCOptions{MinAge: GC_MIN_AGE, Now: gcNow})
+	if err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if report.Scanned != 4 || len(report.Orphans) != 1 || report.Orphans[0].Pathname != "yt-transcribe/youtube/orphan" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.OrphanBytes() != 30 {
+		t.Errorf("want 30 orphan bytes, got %d", report.OrphanBytes())
+	}
+	if len(store.deleted) != 0 || report.Deleted != 0 {
+		t.Errorf("dry run must not delete, deleted %v", store.deleted)
+	}
+}
+
+func TestCollectGarbage_DeletesOrphans(t *testing.T) {
+	store := gcStore()
+	report, err := CollectGarbage(context.Background(), store, gcReferences, GCOptions{MinAge: GC_MIN_AGE, Delete: true, Now: gcNow})
+	if err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if report.Deleted != 1 || len(store.deleted) != 1 || store.deleted[0] != "yt-transcribe/youtube/orphan" {
+		t.Errorf("unexpected deletions %v (report %+v)", store.deleted, report)
+	}
+}
+
+func TestCollectGarbage_CollectsDeleteErrors(t *testing.T) {
+	store := gcStore()
+	store.deleteErr = errors.New("access denied")
+	report, err := CollectGarbage(context.Background(), store, gcReferences, GCOptions{Delete: true, Now: gcNow})
+	if err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if report.Deleted != 0 || len(report.Errors) != 2 {
+		t.Errorf("expected both orphans to fail, got %+v", report)
+	}
+}
+
+func TestCollectGarbage_RefusesToDeleteWithoutReferences(t *testing.T) {
+	store := gcStore()
+	if _, err := CollectGarbage(context.Background(), store, nil, GCOptions{Delete: true, Now: gcNow}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(store.deleted) != 0 {
+		t.Errorf("expected no deletions, got %v", store.deleted)
+	}
+}
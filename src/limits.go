@@ -0,0 +1,59 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRejected is matched (via errors.Is) by every error returned by Limits.Check.
+var ErrRejected = errors.New("video rejected by pre-flight checks")
+
+// RejectionError explains why a video was rejected before downloading.
+// Rejections are permanent: retrying will not change the outcome.
+type RejectionError struct {
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrRejected, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrRejected) report true.
+func (e *RejectionError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// Retryable always reports false.
+func (e *RejectionError) Retryable() bool {
+	return false
+}
+
+// Rejected always reports true, so packages that do not import src can recognise rejections.
+func (e *RejectionError) Rejected() bool {
+	return true
+}
+
+// Limits bounds the videos the service is willing to process. Zero values disable a limit.
+// Live and upcoming streams are always rejected because their length is unknown.
+type Limits struct {
+	MaxDuration time.Duration
+	// MaxFileSize is the maximum estimated size in bytes of the downloaded audio stream.
+	MaxFileSize int64
+}
+
+// Check returns a *RejectionError when meta violates the limits, or nil.
+func (l Limits) Check(meta *MediaMetadata) error {
+	switch {
+	case meta.IsLive:
+		return &RejectionError{Reason: "live streams cannot be transcribed until they have ended"}
+	case meta.IsUpcoming:
+		return &RejectionError{Reason: "upcoming streams cannot be transcribed until they have aired"}
+	case l.MaxDuration > 0 && meta.Duration > l.MaxDuration:
+		return &RejectionError{Reason: fmt.Sprintf("duration %s exceeds the maximum of %s", meta.Duration, l.MaxDuration)}
+	case l.MaxFileSize > 0 && meta.EstimatedSize > l.MaxFileSize:
+		return &RejectionError{Reason: fmt.Sprintf("estimated audio size %d MB exceeds the maximum of %d MB", meta.EstimatedSize>>20, l.MaxFileSize>>20)}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package src
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimitsCheck(t *testing.T) {
+	limits := Limits{MaxDuration: 3 * time.Hour, MaxFileSize: 500 << 20}
+
+	cases := []struct {
+		name   string
+		meta   MediaMetadata
+		reject bool
+	}{
+		{"within limits", MediaMetadata{Duration: time.Hour, EstimatedSize: 100 << 20}, false},
+		{"too long", MediaMetadata{Duration: 12 * time.Hour}, true},
+		{"too large", MediaMetadata{Duration: time.Hour, EstimatedSize: 600 << 20}, true},
+		{"live", MediaMetadata{IsLive: true}, true},
+		{"upcoming", MediaMetadata{IsUpcoming: true}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := limits.Check(&tc.meta)
+			if got := err != nil; got != tc.reject {
+				t.Fatalf("want rejected=%v, got err=%v", tc.reject, err)
+			}
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, ErrRejected) {
+				t.Errorf("expected errors.Is(err, ErrRejected), got %v", err)
+			}
+			if !IsPermanent(err) {
+				t.Errorf("expected rejection to be permanent")
+			}
+			var rejection interface{ Rejected() bool }
+			if !errors.As(err, &rejection) || !rejection.Rejected() {
+				t.Errorf("expected rejection to report Rejected")
+			}
+		})
+	}
+}
+
+func TestLimitsCheck_ZeroValueOnlyRejectsLiveStreams(t *testing.T) {
+	var limits Limits
+
+	if err := limits.Check(&MediaMetadata{Duration: 100 * time.Hour, EstimatedSize: 1 << 40}); err != nil {
+		t.Errorf("expected no rejection without limits, got %v", err)
+	}
+	if err := limits.Check(&MediaMetadata{IsLive: true}); err == nil {
+		t.Error("expected live streams to be rejected without limits")
+	}
+}
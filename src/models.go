@@ -1,7 +1,10 @@
-
 package src
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // VideoDownloader defines the interface for downloading audio from videos.
 // Applying the Interface Segregation Principle (ISP) and Dependency Inversion Principle (DIP).
@@ -9,19 +12,93 @@ type VideoDownloader interface {
 	DownloadAudio(ctx context.Context, videoURL string, outputDir string) (filePath string, videoID string, err error)
 }
 
+// MediaMetadata describes a video as reported by the downloader before any media is fetched.
+type MediaMetadata struct {
+	ID       string
+	Title    string
+	Duration time.Duration
+	// EstimatedSize is the expected size in bytes of the audio stream that will be downloaded, or 0 if unknown.
+	EstimatedSize int64
+	IsLive        bool
+	IsUpcoming    bool
+}
+
+// MetadataProber is implemented by downloaders that can inspect a video without downloading it.
+// TranscriptionServiceImpl uses it for pre-flight checks.
+type MetadataProber interface {
+	Probe(ctx context.Context, videoURL string) (*MediaMetadata, error)
+}
+
 // Transcriber defines the interface for transcribing audio files into text.
 // This adheres to the Interface Segregation Principle (ISP) and Dependency Inversion Principle (DIP).
 type Transcriber interface {
 	Transcribe(ctx context.Context, audioFilePath string) (string, error)
 }
 
+// Embedder turns texts into embedding vectors for semantic search, one vector per text.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NotesGenerator writes Markdown notes (summary, key points and action items) for a transcript.
+type NotesGenerator interface {
+	Notes(ctx context.Context, transcript string) (string, error)
+}
+
 // Uploader defines the interface for uploading content.
 type Uploader interface {
-	Upload(ctx context.Context, content string, filename string) (string, error)
+	Upload(ctx context.Context, content string, filename string) (*UploadResult, error)
+}
+
+// StreamUploader is implemented by uploaders that can store an artifact from a reader without
+// holding it in memory. size is the number of bytes r yields, or negative when unknown.
+// r is consumed, so callers that retry must provide a fresh reader for every attempt.
+type StreamUploader interface {
+	UploadReader(ctx context.Context, r io.Reader, size int64, filename string) (*UploadResult, error)
+}
+
+// UploadResult describes a stored artifact. URL is always set; ETag is empty when the backend has none.
+// Size is the uncompressed size of the artifact.
+type UploadResult struct {
+	URL         string
+	Pathname    string
+	Size        int64
+	ContentType string
+	ETag        string
+}
+
+// Provenance records how a transcript was produced, so changes in transcript quality can be
+// correlated with model and tool upgrades.
+type Provenance struct {
+	// Model is the file name of the whisper model.
+	Model string `json:"model,omitempty"`
+	// ToolVersions maps each external tool (yt-dlp, ffmpeg, whisper-cli) to its version.
+	ToolVersions  map[string]string `json:"toolVersions,omitempty"`
+	TranscribedAt time.Time         `json:"transcribedAt"`
+}
+
+// Result describes a completed transcription job.
+type Result struct {
+	// URL is the uploaded transcript's blob URL.
+	URL        string
+	VideoID    string
+	Provenance Provenance
+	// SHA256 is the hex-encoded hash of the transcript content.
+	SHA256 string
+	// Unchanged reports that the transcript matched the stored one, so it was not uploaded again.
+	Unchanged bool
+	// AudioURL is the archived audio the transcript was made from, or empty when audio is not archived.
+	AudioURL string
+	// NotesURL is the uploaded Markdown notes of the transcript, or empty when no notes were written.
+	NotesURL string
+	// Transcript is the SRT content of the transcript, also when it was not uploaded again.
+	Transcript string
 }
 
 // TranscriptionService defines the interface for the main transcription service.
 type TranscriptionService interface {
 	// Execute orchestrates download → transcribe → upload and returns the uploaded blob URL.
 	Execute(ctx context.Context, videoURL, outputDir string) (blobURL string, err error)
+	// Run is like Execute but returns the full Result, including the transcript's provenance.
+	Run(ctx context.Context, videoURL, outputDir string) (*Result, error)
 }
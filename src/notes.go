@@ -0,0 +1,46 @@
+package src
+
+import (
+	"context"
+	"fmt"
+)
+
+// NOTES_EXT is appended to the transcript path to name the Markdown notes.
+const NOTES_EXT = ".md"
+
+// writeNotes has NotesGenerator write notes on the SRT transcript and uploads them next to the
+// transcript at uploadPath. Both are retried with the upload policy, as they run in the upload
+// stage. It returns the notes URL, or an empty string when no notes could be written: notes are
+// best effort and never fail the job.
+func (s *TranscriptionServiceImpl) writeNotes(ctx context.Context, transcript, uploadPath string) string {
+	// The model gets the spoken text without SRT numbering and timestamps
+	text := transcript
+	if segments, err := ParseSRT(transcript); err == nil && len(segments) > 0 {
+		text = SegmentsText(segments)
+	}
+
+	fmt.Println("Writing notes...")
+	var notes string
+	err := s.Retry.Upload.Do(ctx, STAGE_NOTES, func(ctx context.Context) error {
+		var err error
+		notes, err = s.NotesGenerator.Notes(ctx, text)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("Warning: could not write notes: %v\n", err)
+		return ""
+	}
+
+	var upload *UploadResult
+	err = s.Retry.Upload.Do(ctx, STAGE_UPLOAD, func(ctx context.Context) error {
+		var err error
+		upload, err = s.Uploader.Upload(ctx, notes, uploadPath+NOTES_EXT)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("Warning: could not upload notes: %v\n", err)
+		return ""
+	}
+	fmt.Printf("Notes uploaded: %s\n", upload.URL)
+	return upload.URL
+}
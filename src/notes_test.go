@@ -0,0 +1,99 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubNotes records the transcripts it is asked for notes on and fails the first failures calls
+// with err.
+type stubNotes struct {
+	texts    []string
+	err      error
+	failures int
+}
+
+func (n *stubNotes) Notes(ctx context.Context, transcript string) (string, error) {
+	n.texts = append(n.texts, transcript)
+	if n.failures > 0 {
+		n.failures--
+		return "", n.err
+	}
+	return "## Summary\nGreetings.\n", nil
+}
+
+const notesTranscript = "1\n00:00:00,000 --> 00:00:01,000\nHello\n\n2\n00:00:01,000 --> 00:00:02,000\nworld\n"
+
+func TestRun_UploadsNotesNextToTranscript(t *testing.T) {
+	notes := &stubNotes{}
+	uploader := &countingUploader{}
+	svc := &TranscriptionServiceImpl{Downloader: stubDownloader{}, Transcriber: stubTranscriber{notesTranscript}, Uploader: uploader, Retry: DefaultRetryPolicies(), NotesGenerator: notes}
+
+	result, err := svc.Run(context.Background(), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(notes.texts) != 1 || notes.texts[0] != "Hello\nworld" {
+		t.Errorf("expected notes on the plain transcript text, got %q", notes.texts)
+	}
+	if got := uploader.files["yt-transcribe/youtube/abc.md"]; got != "## Summary\nGreetings.\n" {
+		t.Errorf("expected the notes to be uploaded, got %v", uploader.files)
+	}
+	if result.NotesURL != "https://blob.example.com/yt-transcribe/youtube/abc.md" {
+		t.Errorf("unexpected NotesURL %q", result.NotesURL)
+	}
+}
+
+func TestRun_RetriesNotes(t *testing.T) {
+	notes := &stubNotes{err: &classifiedError{retryable: true}, failures: 1}
+	uploader := &countingUploader{}
+	svc := &TranscriptionServiceImpl{Downloader: stubDownloader{}, Transcriber: stubTranscriber{notesTranscript}, Uploader: uploader, Retry: DefaultRetryPolicies(), NotesGenerator: notes}
+	svc.Retry.Upload = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+
+	result, err := svc.Run(context.Background(), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(notes.texts) != 2 || result.NotesURL == "" {
+		t.Errorf("expected notes on the second attempt, got %d attempts and %+v", len(notes.texts), result)
+	}
+}
+
+func TestRun_NotesFailureDoesNotFailJob(t *testing.T) {
+	uploader := &countingUploader{}
+	svc := &TranscriptionServiceImpl{Downloader: stubDownloader{}, Transcriber: stubTranscriber{notesTranscript}, Uploader: uploader, Retry: DefaultRetryPolicies(), NotesGenerator: &stubNotes{err: errors.New("invalid prompt"), failures: 1}}
+
+	result, err := svc.Run(context.Background(), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(uploader.files) != 1 || result.URL == "" || result.NotesURL != "" {
+		t.Errorf("expected the transcript without notes, got %v and %+v", uploader.files, result)
+	}
+}
+
+func TestRun_UnchangedTranscriptKeepsStoredNotes(t *testing.T) {
+	notes := &stubNotes{}
+	svc := &TranscriptionServiceImpl{Downloader: stubDownloader{}, Transcriber: stubTranscriber{notesTranscript}, Uploader: &countingUploader{}, Retry: DefaultRetryPolicies(), NotesGenerator: notes}
+	stored := StoredTranscript{URL: "https://blob.example.com/old", SHA256: ContentSHA256(notesTranscript), NotesURL: "https://blob.example.com/old.md"}
+
+	result, err := svc.Run(WithStoredTranscript(context.Background(), stored), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(notes.texts) != 0 || result.NotesURL != stored.NotesURL {
+		t.Errorf("expected the stored notes to be kept, got %q and %+v", notes.texts, result)
+	}
+
+	// Without stored notes, notes are written for the unchanged transcript
+	stored.NotesURL = ""
+	result, err = svc.Run(WithStoredTranscript(context.Background(), stored), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(notes.texts) != 1 || result.NotesURL != "https://blob.example.com/yt-transcribe/youtube/abc.md" {
+		t.Errorf("expected notes to be written, got %+v", result)
+	}
+}
@@ -0,0 +1,37 @@
+package src
+
+import (
+	"strings"
+)
+
+// DEFAULT_PASSAGE_CHARS is the target length of the passages embedded for semantic search,
+// roughly 250 tokens of English.
+const DEFAULT_PASSAGE_CHARS = 1000
+
+// ChunkPassages joins consecutive segments into passages of at most maxChars characters, so each
+// passage keeps the start of its first segment and the end of its last. A segment longer than
+// maxChars becomes a passage of its own.
+func ChunkPassages(segments []Segment, maxChars int) []Segment {
+	var passages []Segment
+	var text strings.Builder
+	var current Segment
+	for _, segment := range segments {
+		if text.Len() > 0 && text.Len()+1+len(segment.Text) > maxChars {
+			current.Text = text.String()
+			passages = append(passages, current)
+			text.Reset()
+		}
+		if text.Len() == 0 {
+			current.Start = segment.Start
+		} else {
+			text.WriteByte(' ')
+		}
+		text.WriteString(segment.Text)
+		current.End = segment.End
+	}
+	if text.Len() > 0 {
+		current.Text = text.String()
+		passages = append(passages, current)
+	}
+	return passages
+}
@@ -0,0 +1,30 @@
+package src
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChunkPassages(t *testing.T) {
+	segments := []Segment{
+		{Start: 0, End: time.Second, Text: "one two"},
+		{Start: time.Second, End: 2 * time.Second, Text: "three"},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: strings.Repeat("x", 20)},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "four"},
+	}
+
+	passages := ChunkPassages(segments, 14)
+	want := []Segment{
+		{Start: 0, End: 2 * time.Second, Text: "one two three"},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: strings.Repeat("x", 20)},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "four"},
+	}
+	if !reflect.DeepEqual(passages, want) {
+		t.Errorf("want %+v, got %+v", want, passages)
+	}
+	if passages := ChunkPassages(nil, 14); passages != nil {
+		t.Errorf("want no passages, got %+v", passages)
+	}
+}
@@ -0,0 +1,287 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of PipelineOptions. Downloads are network-bound and overlap well; whisper.cpp already
+// uses several threads per transcription, so one transcriber keeps the CPU busy.
+const (
+	DEFAULT_PIPELINE_DOWNLOADERS   = 2
+	DEFAULT_PIPELINE_TRANSCRIBERS  = 1
+	DEFAULT_PIPELINE_UPLOADERS     = 2
+	DEFAULT_PIPELINE_QUEUE_SIZE    = 2
+	DEFAULT_PIPELINE_MAX_IN_FLIGHT = 6
+)
+
+// PipelineOptions sizes the stages of RunPipeline.
+type PipelineOptions struct {
+	// Downloaders, Transcribers and Uploaders are the number of workers of each stage.
+	Downloaders  int
+	Transcribers int
+	Uploaders    int
+	// QueueSize is the capacity of the queue in front of each stage.
+	QueueSize int
+	// MaxInFlight caps the jobs that have been started but not yet reported, across all stages and
+	// queues. It bounds the working directories, and so the disk space, in use at once.
+	MaxInFlight int
+}
+
+// DefaultPipelineOptions returns the DEFAULT_PIPELINE_* options.
+func DefaultPipelineOptions() PipelineOptions {
+	return PipelineOptions{
+		Downloaders:  DEFAULT_PIPELINE_DOWNLOADERS,
+		Transcribers: DEFAULT_PIPELINE_TRANSCRIBERS,
+		Uploaders:    DEFAULT_PIPELINE_UPLOADERS,
+		QueueSize:    DEFAULT_PIPELINE_QUEUE_SIZE,
+		MaxInFlight:  DEFAULT_PIPELINE_MAX_IN_FLIGHT,
+	}
+}
+
+// Validate reports options that would stall the pipeline.
+func (o PipelineOptions) Validate() error {
+	if o.Downloaders < 1 || o.Transcribers < 1 || o.Uploaders < 1 {
+		return fmt.Errorf("every pipeline stage needs at least one worker")
+	}
+	if o.QueueSize < 0 {
+		return fmt.Errorf("pipeline queue size must not be negative")
+	}
+	if o.MaxInFlight < 1 {
+		return fmt.Errorf("pipeline max in flight must be at least 1")
+	}
+	return nil
+}
+
+// PipelineJob is a video submitted to RunPipeline.
+type PipelineJob struct {
+	VideoURL string
+	// Context is the context the job runs with, e.g. one returned by WithAttemptLog. It should be
+	// derived from the context passed to RunPipeline; nil means that context.
+	Context context.Context
+	// Key is returned unchanged in the PipelineResult, so callers can match results to their jobs.
+	Key any
+}
+
+// PipelineResult is the outcome of a PipelineJob, as Run would have returned it.
+type PipelineResult struct {
+	Job    PipelineJob
+	Result *Result
+	Err    error
+}
+
+// StageMetrics describes the work of one pipeline stage.
+type StageMetrics struct {
+	Workers   int
+	Completed int
+	Failed    int
+	// Busy is the time the workers spent on jobs, summed over the workers.
+	Busy time.Duration
+	// Waiting is the time jobs spent in the queue in front of the stage, summed over the jobs.
+	Waiting time.Duration
+	// MaxQueued is the longest the queue in front of the stage has been.
+	MaxQueued int
+}
+
+// Utilization returns the share of the elapsed time the workers of the stage were busy.
+func (m StageMetrics) Utilization(elapsed time.Duration) float64 {
+	if elapsed <= 0 || m.Workers == 0 {
+		return 0
+	}
+	return float64(m.Busy) / float64(elapsed*time.Duration(m.Workers))
+}
+
+// PipelineMetrics are reported by RunPipeline once every job has been reported.
+type PipelineMetrics struct {
+	Jobs       int
+	Elapsed    time.Duration
+	Download   StageMetrics
+	Transcribe StageMetrics
+	Upload     StageMetrics
+}
+
+// String formats one line per stage.
+func (m *PipelineMetrics) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d job(s) in %s", m.Jobs, m.Elapsed.Round(time.Second))
+	for _, stage := range []struct {
+		name string
+		m    StageMetrics
+	}{{STAGE_DOWNLOAD, m.Download}, {STAGE_TRANSCRIBE, m.Transcribe}, {STAGE_UPLOAD, m.Upload}} {
+		fmt.Fprintf(&b, "\n  %-10s %d worker(s), %d ok, %d failed, busy %s (%.0f%%), queued %s, max queue %d",
+			stage.name, stage.m.Workers, stage.m.Completed, stage.m.Failed, stage.m.Busy.Round(time.Second),
+			100*stage.m.Utilization(m.Elapsed), stage.m.Waiting.Round(time.Second), stage.m.MaxQueued)
+	}
+	return b.String()
+}
+
+// PipelineRunner is implemented by services that can process many videos concurrently.
+type PipelineRunner interface {
+	RunPipeline(ctx context.Context, jobs iter.Seq[PipelineJob], outputDir string, opts PipelineOptions, report func(PipelineResult)) (*PipelineMetrics, error)
+}
+
+// pipelineItem is a job moving through the stages.
+type pipelineItem struct {
+	index    int
+	job      PipelineJob
+	ctx      context.Context
+	state    *transcriptionJob
+	result   *Result
+	err      error
+	enqueued time.Time
+}
+
+// pipelineStage is the queue in front of a stage and the metrics of its workers.
+type pipelineStage struct {
+	queue   chan *pipelineItem
+	mu      *sync.Mutex
+	metrics *StageMetrics
+}
+
+func (st pipelineStage) push(item *pipelineItem) {
+	item.enqueued = time.Now()
+	st.mu.Lock()
+	st.metrics.MaxQueued = max(st.metrics.MaxQueued, len(st.queue)+1)
+	st.mu.Unlock()
+	st.queue <- item
+}
+
+// RunPipeline runs the jobs like Run, but with the stages pipelined: opts.Downloaders workers
+// download audio into bounded queues, from which opts.Transcribers workers transcribe, and
+// opts.Uploaders workers archive and upload the results. Downloads for later jobs thus overlap
+// with the transcription of earlier ones.
+//
+// report is called from the goroutine that called RunPipeline, once per job, in the order the jobs
+// were drawn from jobs, so callers can checkpoint their progress. A job that is finished waits for
+// the jobs before it to be reported, and still counts towards opts.MaxInFlight until it is.
+// When ctx is cancelled, no further jobs are drawn and the running ones fail with ctx's error.
+func (s *TranscriptionServiceImpl) RunPipeline(ctx context.Context, jobs iter.Seq[PipelineJob], outputDir string, opts PipelineOptions, report func(PipelineResult)) (*PipelineMetrics, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	metrics := &PipelineMetrics{}
+	metrics.Download.Workers = opts.Downloaders
+	metrics.Transcribe.Workers = opts.Transcribers
+	metrics.Upload.Workers = opts.Uploaders
+
+	var mu sync.Mutex
+	downloads := pipelineStage{make(chan *pipelineItem, opts.QueueSize), &mu, &metrics.Download}
+	transcriptions := pipelineStage{make(chan *pipelineItem, opts.QueueSize), &mu, &metrics.Transcribe}
+	uploads := pipelineStage{make(chan *pipelineItem, opts.QueueSize), &mu, &metrics.Upload}
+	// At most MaxInFlight items exist, so the uploaders never block on done.
+	done := make(chan *pipelineItem, opts.MaxInFlight)
+	slots := make(chan struct{}, opts.MaxInFlight)
+
+	// Admission: draw jobs while there is a free slot.
+	go func() {
+		defer close(downloads.queue)
+		index := 0
+		for job := range jobs {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			item := &pipelineItem{index: index, job: job, ctx: job.Context}
+			if item.ctx == nil {
+				item.ctx = ctx
+			}
+			index++
+			downloads.push(item)
+		}
+	}()
+
+	// finish hands an item to the reporter, removing its working directory.
+	finish := func(item *pipelineItem) {
+		if item.state != nil {
+			item.state.close()
+		}
+		done <- item
+	}
+
+	runWorkers(opts.Downloaders, downloads, transcriptions.queue, func(item *pipelineItem) error {
+		if err := item.ctx.Err(); err != nil {
+			return err
+		}
+		state, err := s.startJob(item.ctx, item.job.VideoURL, outputDir)
+		if err != nil {
+			return err
+		}
+		item.state = state
+		return s.downloadStage(item.ctx, state)
+	}, transcriptions.push, finish)
+
+	runWorkers(opts.Transcribers, transcriptions, uploads.queue, func(item *pipelineItem) error {
+		return s.transcribeStage(item.ctx, item.state)
+	}, uploads.push, finish)
+
+	runWorkers(opts.Uploaders, uploads, done, func(item *pipelineItem) error {
+		var err error
+		item.result, err = s.uploadStage(item.ctx, item.state)
+		return err
+	}, finish, finish)
+
+	// Report in submission order.
+	pending := map[int]*pipelineItem{}
+	next := 0
+	for item := range done {
+		pending[item.index] = item
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			metrics.Jobs++
+			report(PipelineResult{Job: ready.job, Result: ready.result, Err: ready.err})
+			<-slots
+		}
+	}
+
+	metrics.Elapsed = time.Since(started)
+	return metrics, nil
+}
+
+// runWorkers starts n workers that take items from in, run work on them and pass them on with
+// forward, or to fail with the error. The channel out is closed once every worker has exited.
+func runWorkers(n int, in pipelineStage, out chan *pipelineItem, work func(*pipelineItem) error, forward, fail func(*pipelineItem)) {
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range in.queue {
+				start := time.Now()
+				err := work(item)
+				busy := time.Since(start)
+
+				in.mu.Lock()
+				in.metrics.Waiting += start.Sub(item.enqueued)
+				in.metrics.Busy += busy
+				if err != nil {
+					in.metrics.Failed++
+				} else {
+					in.metrics.Completed++
+				}
+				in.mu.Unlock()
+
+				if err != nil {
+					item.err = err
+					fail(item)
+					continue
+				}
+				forward(item)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+}
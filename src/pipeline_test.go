@@ -0,0 +1,216 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pipelineDownloader writes a file named after the last path segment of the URL and fails for failing.
+type pipelineDownloader struct {
+	failing   string
+	downloads atomic.Int32
+	// downloaded is closed once `after` downloads have completed.
+	after      int32
+	downloaded chan struct{}
+}
+
+func (d *pipelineDownloader) DownloadAudio(ctx context.Context, videoURL, outputDir string) (string, string, error) {
+	if videoURL == d.failing {
+		return "", "", errors.New("404 Not Found")
+	}
+	id := path.Base(videoURL)
+	audio := outputDir + "/" + id + ".wav"
+	if err := os.WriteFile(audio, []byte(id), 0644); err != nil {
+		return "", "", err
+	}
+	if n := d.downloads.Add(1); d.downloaded != nil && n == d.after {
+		close(d.downloaded)
+	}
+	return audio, id, nil
+}
+
+// pipelineTranscriber returns the audio file content, after waiting for gate when it is set and
+// for delays[content].
+type pipelineTranscriber struct {
+	gate   chan struct{}
+	delays map[string]time.Duration
+}
+
+func (t *pipelineTranscriber) Transcribe(ctx context.Context, audioFilePath string) (string, error) {
+	data, err := os.ReadFile(audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	if t.gate != nil {
+		select {
+		case <-t.gate:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	time.Sleep(t.delays[string(data)])
+	return string(data), nil
+}
+
+func pipelineJobs(ids ...string) func(func(PipelineJob) bool) {
+	return func(yield func(PipelineJob) bool) {
+		for _, id := range ids {
+			if !yield(PipelineJob{VideoURL: "https://media.example.com/" + id, Key: id}) {
+				return
+			}
+		}
+	}
+}
+
+func TestRunPipeline_ReportsInSubmissionOrder(t *testing.T) {
+	transcriber := &pipelineTranscriber{delays: map[string]time.Duration{"a": 50 * time.Millisecond}}
+	svc := &TranscriptionServiceImpl{Downloader: &pipelineDownloader{}, Transcriber: transcriber, Uploader: &lockedUploader{}, Retry: DefaultRetryPolicies()}
+	opts := PipelineOptions{Downloaders: 2, Transcribers: 2, Uploaders: 1, QueueSize: 1, MaxInFlight: 4}
+	outputDir := t.TempDir()
+
+	var keys []any
+	metrics, err := svc.RunPipeline(context.Background(), pipelineJobs("a", "b", "c", "d"), outputDir, opts, func(r PipelineResult) {
+		if r.Err != nil {
+			t.Errorf("%v failed: %v", r.Job.Key, r.Err)
+			return
+		}
+		if want := "https://blob.example.com/yt-transcribe/other/" + r.Job.Key.(string); r.Result.URL != want {
+			t.Errorf("want %s, got %s", want, r.Result.URL)
+		}
+		keys = append(keys, r.Job.Key)
+	})
+	if err != nil {
+		t.Fatalf("RunPipeline failed: %v", err)
+	}
+	if !slices.Equal(keys, []any{"a", "b", "c", "d"}) {
+		t.Errorf("want results in submission order, got %v", keys)
+	}
+	if metrics.Jobs != 4 || metrics.Download.Completed != 4 || metrics.Transcribe.Completed != 4 || metrics.Upload.Completed != 4 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("expected every job directory to be removed, found %d", len(entries))
+	}
+}
+
+func TestRunPipeline_DownloadsOverlapTranscription(t *testing.T) {
+	// The first transcription only finishes once three downloads have completed.
+	downloader := &pipelineDownloader{after: 3, downloaded: make(chan struct{})}
+	transcriber := &pipelineTranscriber{gate: downloader.downloaded}
+	svc := &TranscriptionServiceImpl{Downloader: downloader, Transcriber: transcriber, Uploader: &lockedUploader{}, Retry: DefaultRetryPolicies()}
+	opts := PipelineOptions{Downloaders: 2, Transcribers: 1, Uploaders: 1, QueueSize: 1, MaxInFlight: 4}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	failed := 0
+	if _, err := svc.RunPipeline(ctx, pipelineJobs("a", "b", "c"), t.TempDir(), opts, func(r PipelineResult) {
+		if r.Err != nil {
+			failed++
+		}
+	}); err != nil {
+		t.Fatalf("RunPipeline failed: %v", err)
+	}
+	if failed != 0 {
+		t.Errorf("expected downloads to continue while the transcriber is busy, %d job(s) failed", failed)
+	}
+}
+
+// lockedUploader is a countingUploader that can be shared by concurrent uploaders.
+type lockedUploader struct {
+	countingUploader
+	mu sync.Mutex
+}
+
+func (u *lockedUploader) Upload(ctx context.Context, content, filename string) (*UploadResult, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.countingUploader.Upload(ctx, content, filename)
+}
+
+func TestRunPipeline_CapsJobsInFlight(t *testing.T) {
+	downloader := &pipelineDownloader{}
+	transcriber := &pipelineTranscriber{delays: map[string]time.Duration{"a": 100 * time.Millisecond}}
+	svc := &TranscriptionServiceImpl{Downloader: downloader, Transcriber: transcriber, Uploader: &lockedUploader{}, Retry: DefaultRetryPolicies()}
+	opts := PipelineOptions{Downloaders: 4, Transcribers: 4, Uploaders: 4, QueueSize: 4, MaxInFlight: 2}
+
+	reported := 0
+	maxAhead := int32(0)
+	if _, err := svc.RunPipeline(context.Background(), pipelineJobs("a", "b", "c", "d", "e", "f"), t.TempDir(), opts, func(r PipelineResult) {
+		// Job "a" holds back the report of the others, so downloads must not run ahead of it.
+		maxAhead = max(maxAhead, downloader.downloads.Load()-int32(reported))
+		reported++
+	}); err != nil {
+		t.Fatalf("RunPipeline failed: %v", err)
+	}
+	if maxAhead > int32(opts.MaxInFlight) {
+		t.Errorf("want at most %d jobs in flight, saw %d", opts.MaxInFlight, maxAhead)
+	}
+}
+
+func TestRunPipeline_ReportsFailures(t *testing.T) {
+	svc := &TranscriptionServiceImpl{Downloader: &pipelineDownloader{failing: "https://media.example.com/b"}, Transcriber: &pipelineTranscriber{}, Uploader: &lockedUploader{}, Retry: DefaultRetryPolicies()}
+	outputDir := t.TempDir()
+
+	var errs []string
+	metrics, err := svc.RunPipeline(context.Background(), pipelineJobs("a", "b", "c"), outputDir, DefaultPipelineOptions(), func(r PipelineResult) {
+		errs = append(errs, fmt.Sprint(r.Err))
+	})
+	if err != nil {
+		t.Fatalf("RunPipeline failed: %v", err)
+	}
+	if errs[0] != "<nil>" || errs[1] == "<nil>" || errs[2] != "<nil>" {
+		t.Errorf("want only the second job to fail, got %v", errs)
+	}
+	if metrics.Download.Failed != 1 || metrics.Upload.Completed != 2 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("expected every job directory to be removed, found %d", len(entries))
+	}
+}
+
+func TestRunPipeline_StopsDrawingJobsWhenCancelled(t *testing.T) {
+	svc := &TranscriptionServiceImpl{Downloader: &pipelineDownloader{}, Transcriber: &pipelineTranscriber{}, Uploader: &lockedUploader{}, Retry: DefaultRetryPolicies()}
+	ctx, cancel := context.WithCancel(context.Background())
+	drawn := 0
+	jobs := func(yield func(PipelineJob) bool) {
+		for i := 0; ; i++ {
+			drawn++
+			if !yield(PipelineJob{VideoURL: fmt.Sprintf("https://media.example.com/%d", i)}) {
+				return
+			}
+		}
+	}
+
+	if _, err := svc.RunPipeline(ctx, jobs, t.TempDir(), DefaultPipelineOptions(), func(r PipelineResult) {
+		cancel()
+	}); err != nil {
+		t.Fatalf("RunPipeline failed: %v", err)
+	}
+	if drawn > DEFAULT_PIPELINE_MAX_IN_FLIGHT+2 {
+		t.Errorf("expected drawing to stop after cancellation, drew %d jobs", drawn)
+	}
+}
+
+func TestPipelineOptions_Validate(t *testing.T) {
+	if err := DefaultPipelineOptions().Validate(); err != nil {
+		t.Errorf("defaults must be valid: %v", err)
+	}
+	for _, opts := range []PipelineOptions{
+		{Downloaders: 0, Transcribers: 1, Uploaders: 1, MaxInFlight: 1},
+		{Downloaders: 1, Transcribers: 1, Uploaders: 1, QueueSize: -1, MaxInFlight: 1},
+		{Downloaders: 1, Transcribers: 1, Uploaders: 1, MaxInFlight: 0},
+	} {
+		if opts.Validate() == nil {
+			t.Errorf("expected %+v to be invalid", opts)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	STAGE_PROBE      = "probe"
+	STAGE_DOWNLOAD   = "download"
+	STAGE_TRANSCRIBE = "transcribe"
+	STAGE_UPLOAD     = "upload"
+	STAGE_NOTES      = "notes"
+)
+
+// MAX_RETRY_DELAY caps the delay of policies without a MaxDelay, so the doubling backoff cannot
+// overflow for large attempt counts.
+const MAX_RETRY_DELAY = 24 * time.Hour
+
+// randFloat64 is a variable that can be overridden for testing purposes.
+var randFloat64 = rand.Float64
+
+// RetryPolicy controls how often, and how far apart, a pipeline stage is retried.
+// Only errors for which IsRetryable reports true are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values below 1 mean a single attempt.
+	MaxAttempts int
+	// BaseDelay is the delay after the first failed attempt; it doubles with every further attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts. Zero means MAX_RETRY_DELAY.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0–1) of each delay that is randomised to avoid retry storms.
+	Jitter float64
+}
+
+// RetryPolicies holds a separate RetryPolicy for each pipeline stage.
+type RetryPolicies struct {
+	Download   RetryPolicy
+	Transcribe RetryPolicy
+	Upload     RetryPolicy
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured:
+// three attempts, starting at two seconds and capped at thirty.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// DefaultRetryPolicies returns DefaultRetryPolicy for every stage.
+func DefaultRetryPolicies() RetryPolicies {
+	return RetryPolicies{
+		Download:   DefaultRetryPolicy(),
+		Transcribe: DefaultRetryPolicy(),
+		Upload:     DefaultRetryPolicy(),
+	}
+}
+
+// Validate reports whether the policy values are usable.
+func (p RetryPolicy) Validate() error {
+	if p.BaseDelay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("retry delays must not be negative")
+	}
+	if p.MaxDelay > 0 && p.BaseDelay > p.MaxDelay {
+		return fmt.Errorf("retry base delay %s exceeds max delay %s", p.BaseDelay, p.MaxDelay)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("retry jitter must be between 0 and 1, got %v", p.Jitter)
+	}
+	return nil
+}
+
+// Delay returns the backoff to wait after the given failed attempt (1-based),
+// with up to Jitter of it randomised.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	maxDelay := p.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = MAX_RETRY_DELAY
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	if p.Jitter > 0 {
+		spread := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - spread + 2*spread*randFloat64())
+	}
+	return delay
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, the attempts are exhausted
+// or ctx is cancelled. Every failed attempt is logged and added to the AttemptLog in ctx, if any.
+func (p RetryPolicy) Do(ctx context.Context, stage string, fn func(ctx context.Context) error) error {
+	maxAttempts := max(p.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		retry := attempt < maxAttempts && IsRetryable(err) && ctx.Err() == nil
+		var delay time.Duration
+		if retry {
+			delay = p.Delay(attempt)
+		}
+		attemptLogFrom(ctx).add(Attempt{Stage: stage, Number: attempt, Err: err, Delay: delay, At: time.Now()})
+
+		if !retry {
+			return err
+		}
+
+		log.Printf("%s attempt %d/%d failed, retrying in %s: %v", stage, attempt, maxAttempts, delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Attempt describes one failed attempt of a pipeline stage.
+type Attempt struct {
+	Stage  string
+	Number int
+	Err    error
+	// Delay is the backoff before the next attempt, or zero when the stage gave up.
+	Delay time.Duration
+	At    time.Time
+}
+
+// AttemptLog collects the failed attempts of a single job. It is safe for concurrent use.
+type AttemptLog struct {
+	mu       sync.Mutex
+	attempts []Attempt
+}
+
+type attemptLogKey struct{}
+
+// WithAttemptLog returns a context that records failed attempts into the returned AttemptLog.
+func WithAttemptLog(ctx context.Context) (context.Context, *AttemptLog) {
+	l := &AttemptLog{}
+	return context.WithValue(ctx, attemptLogKey{}, l), l
+}
+
+// attemptLogFrom returns the AttemptLog stored in ctx, or nil.
+func attemptLogFrom(ctx context.Context) *AttemptLog {
+	l, _ := ctx.Value(attemptLogKey{}).(*AttemptLog)
+	return l
+}
+
+func (l *AttemptLog) add(a Attempt) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts = append(l.attempts, a)
+}
+
+// Attempts returns a copy of the recorded attempts in the order they happened.
+func (l *AttemptLog) Attempts() []Attempt {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Attempt(nil), l.attempts...)
+}
@@ -0,0 +1,155 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type classifiedError struct {
+	retryable bool
+}
+
+func (e *classifiedError) Error() string   { return "classified" }
+func (e *classifiedError) Retryable() bool { return e.retryable }
+
+func TestRetryPolicyDelay_DoublesAndCaps(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d): want %s, got %s", i+1, w, got)
+		}
+	}
+}
+
+func TestRetryPolicyDelay_UncappedSaturates(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second}
+	for _, attempt := range []int{40, 100, 1000} {
+		if got := p.Delay(attempt); got != MAX_RETRY_DELAY {
+			t.Errorf("Delay(%d): want %s, got %s", attempt, MAX_RETRY_DELAY, got)
+		}
+	}
+}
+
+func TestRetryPolicyDelay_AppliesJitter(t *testing.T) {
+	old := randFloat64
+	t.Cleanup(func() { randFloat64 = old })
+
+	p := RetryPolicy{BaseDelay: 10 * time.Second, Jitter: 0.5}
+
+	randFloat64 = func() float64 { return 0 }
+	if got := p.Delay(1); got != 5*time.Second {
+		t.Errorf("lowest jitter: want 5s, got %s", got)
+	}
+	randFloat64 = func() float64 { return 1 }
+	if got := p.Delay(1); got != 15*time.Second {
+		t.Errorf("highest jitter: want 15s, got %s", got)
+	}
+}
+
+func TestRetryPolicyDo_RetriesRetryableErrors(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	ctx, attemptLog := WithAttemptLog(context.Background())
+
+	calls := 0
+	err := p.Do(ctx, STAGE_DOWNLOAD, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return &classifiedError{retryable: true}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls: want 3, got %d", calls)
+	}
+
+	attempts := attemptLog.Attempts()
+	if len(attempts) != 2 {
+		t.Fatalf("recorded attempts: want 2, got %d", len(attempts))
+	}
+	if attempts[0].Stage != STAGE_DOWNLOAD || attempts[0].Number != 1 || attempts[1].Number != 2 {
+		t.Errorf("unexpected attempts: %+v", attempts)
+	}
+}
+
+func TestRetryPolicyDo_StopsOnPermanentError(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+	permanent := &classifiedError{retryable: false}
+
+	calls := 0
+	err := p.Do(context.Background(), STAGE_UPLOAD, func(ctx context.Context) error {
+		calls++
+		return permanent
+	})
+	if !errors.Is(err, permanent) {
+		t.Fatalf("want %v, got %v", permanent, err)
+	}
+	if calls != 1 {
+		t.Errorf("calls: want 1, got %d", calls)
+	}
+}
+
+func TestRetryPolicyDo_StopsOnUnclassifiedError(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+
+	calls := 0
+	_ = p.Do(context.Background(), STAGE_TRANSCRIBE, func(ctx context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	if calls != 1 {
+		t.Errorf("calls: want 1, got %d", calls)
+	}
+}
+
+func TestRetryPolicyDo_GivesUpAfterMaxAttempts(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+
+	calls := 0
+	err := p.Do(context.Background(), STAGE_DOWNLOAD, func(ctx context.Context) error {
+		calls++
+		return &classifiedError{retryable: true}
+	})
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if calls != 2 {
+		t.Errorf("calls: want 2, got %d", calls)
+	}
+}
+
+func TestRetryPolicyDo_StopsWhenContextCancelled(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := p.Do(ctx, STAGE_DOWNLOAD, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return &classifiedError{retryable: true}
+	})
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("calls: want 1, got %d", calls)
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	if err := DefaultRetryPolicy().Validate(); err != nil {
+		t.Errorf("default policy should be valid: %v", err)
+	}
+	if err := (RetryPolicy{BaseDelay: time.Minute, MaxDelay: time.Second}).Validate(); err == nil {
+		t.Error("expected an error when base delay exceeds max delay")
+	}
+	if err := (RetryPolicy{Jitter: 2}).Validate(); err == nil {
+		t.Error("expected an error for jitter above 1")
+	}
+}
@@ -0,0 +1,42 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yt-transcribe/pkg/repository"
+)
+
+// PassageFinder finds the stored passages nearest to an embedding. It is satisfied by
+// repository.PassageStore.
+type PassageFinder interface {
+	NearestPassages(ctx context.Context, model string, embedding []float32, limit int) ([]repository.PassageMatch, error)
+}
+
+// SemanticSearcher answers natural-language queries by embedding them with the model the
+// passages were stored with.
+type SemanticSearcher struct {
+	Embedder Embedder
+	Store    PassageFinder
+	Model    string
+}
+
+// Search returns the limit passages nearest to query; a limit of 0 or less selects
+// repository.DEFAULT_SEMANTIC_LIMIT and it is capped at repository.MAX_SEARCH_LIMIT.
+func (s *SemanticSearcher) Search(ctx context.Context, query string, limit int) ([]repository.PassageMatch, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	if limit <= 0 {
+		limit = repository.DEFAULT_SEMANTIC_LIMIT
+	}
+	vectors, err := s.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return nil, fmt.Errorf("failed to embed query: no embedding returned")
+	}
+	return s.Store.NearestPassages(ctx, s.Model, vectors[0], min(limit, repository.MAX_SEARCH_LIMIT))
+}
@@ -0,0 +1,60 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"yt-transcribe/pkg/repository"
+)
+
+type stubEmbedder struct {
+	vectors [][]float32
+	err     error
+}
+
+func (e stubEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	return e.vectors, e.err
+}
+
+type stubPassageFinder struct {
+	model     string
+	embedding []float32
+	limit     int
+}
+
+func (f *stubPassageFinder) NearestPassages(_ context.Context, model string, embedding []float32, limit int) ([]repository.PassageMatch, error) {
+	f.model, f.embedding, f.limit = model, embedding, limit
+	return []repository.PassageMatch{{Item: repository.MediaItem{ID: "a"}}}, nil
+}
+
+func TestSemanticSearcher_EmbedsQueryWithStoredModel(t *testing.T) {
+	store := &stubPassageFinder{}
+	searcher := &SemanticSearcher{Embedder: stubEmbedder{vectors: [][]float32{{0.5, -1}}}, Store: store, Model: "nomic-embed-text"}
+
+	matches, err := searcher.Search(context.Background(), "connection pooling", 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(matches) != 1 || store.model != "nomic-embed-text" || len(store.embedding) != 2 || store.limit != repository.DEFAULT_SEMANTIC_LIMIT {
+		t.Errorf("unexpected search %+v with %+v", store, matches)
+	}
+	if _, err := searcher.Search(context.Background(), "pooling", 1000); err != nil || store.limit != repository.MAX_SEARCH_LIMIT {
+		t.Errorf("want the limit capped at %d, got %d (%v)", repository.MAX_SEARCH_LIMIT, store.limit, err)
+	}
+}
+
+func TestSemanticSearcher_Errors(t *testing.T) {
+	store := &stubPassageFinder{}
+	for name, searcher := range map[string]*SemanticSearcher{
+		"embedding fails": {Embedder: stubEmbedder{err: errors.New("503 Service Unavailable")}, Store: store},
+		"no embedding":    {Embedder: stubEmbedder{}, Store: store},
+	} {
+		if _, err := searcher.Search(context.Background(), "pooling", 5); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := (&SemanticSearcher{Embedder: stubEmbedder{}, Store: store}).Search(context.Background(), " ", 5); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
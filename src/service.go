@@ -2,11 +2,9 @@ package src
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"os"
 	"strings"
+	"time"
 )
 
 const (
@@ -16,78 +14,228 @@ const (
 	PLATFORM_INSTAGRAM = "instagram"
 )
 
-// vercelBlobResponse represents the JSON response from the Vercel Blob API.
-type vercelBlobResponse struct {
-	URL                string `json:"url"`
-	Pathname           string `json:"pathname"`
-	ContentType        string `json:"contentType"`
-	ContentDisposition string `json:"contentDisposition"`
-}
-
 // TranscriptionServiceImpl is the implementation of the TranscriptionService interface.
 type TranscriptionServiceImpl struct {
 	Downloader  VideoDownloader
 	Transcriber Transcriber
 	Uploader    Uploader
+	// Retry controls how transient failures of each stage are retried.
+	Retry RetryPolicies
+	// Limits rejects videos that are too long or too large before they are downloaded.
+	// It is only enforced when Downloader implements MetadataProber.
+	Limits Limits
+	// FreeSpace reports the bytes available on the filesystem holding a path. When set, each job
+	// checks that its output directory and the temp directory have room before downloading, and
+	// reserves its estimated size until it finishes so concurrent jobs cannot overcommit the disk.
+	FreeSpace func(path string) (uint64, error)
+	// MinFreeSpace is the headroom in bytes that must remain free on each checked filesystem.
+	MinFreeSpace uint64
+	// reservations is the disk space estimated for the jobs in flight.
+	reservations diskReservations
+	// AudioEncoder, when set, compresses the downloaded audio, which is then uploaded next to the
+	// transcript as {videoID}.opus. The Uploader must implement StreamUploader.
+	AudioEncoder AudioEncoder
+	// NotesGenerator, when set, writes notes on each transcript, which are uploaded next to it as
+	// {videoID}.md.
+	NotesGenerator NotesGenerator
+	// Model and ToolVersions are copied into the Provenance of every Result.
+	Model        string
+	ToolVersions map[string]string
 }
 
-// NewTranscriptionService creates a new TranscriptionServiceImpl.
+// NewTranscriptionService creates a new TranscriptionServiceImpl using the default retry policies.
 func NewTranscriptionService(downloader VideoDownloader, transcriber Transcriber, uploader Uploader) TranscriptionService {
 	return &TranscriptionServiceImpl{
 		Downloader:  downloader,
 		Transcriber: transcriber,
 		Uploader:    uploader,
+		Retry:       DefaultRetryPolicies(),
 	}
 }
 
 // Execute orchestrates the download, transcription, and upload processes.
-// It returns the Vercel Blob URL of the uploaded transcript.
+// It returns the URL of the uploaded transcript; see Run for details.
 func (s *TranscriptionServiceImpl) Execute(ctx context.Context, videoURL, outputDir string) (string, error) {
-	// 1. Download the audio
-	fmt.Println("Downloading audio...")
-	audioFilePath, videoID, err := s.Downloader.DownloadAudio(ctx, videoURL, outputDir)
+	result, err := s.Run(ctx, videoURL, outputDir)
 	if err != nil {
-		return "", fmt.Errorf("error downloading audio: %w", err)
+		return "", err
 	}
-	fmt.Printf("Audio downloaded to: %s\n", audioFilePath)
-	defer func() {
-		if err := os.Remove(audioFilePath); err != nil {
-			log.Printf("Warning: could not remove temporary audio file %s: %v", audioFilePath, err)
+	return result.URL, nil
+}
+
+// Run orchestrates the download, transcription, and upload processes.
+// Each stage is retried according to its RetryPolicy; use WithAttemptLog to collect the failed attempts,
+// WithStoredTranscript to skip uploading a transcript that has not changed and WithArchivedAudio to
+// transcribe previously archived audio.
+// Intermediate files are written to a per-job directory inside outputDir that is always removed afterwards.
+func (s *TranscriptionServiceImpl) Run(ctx context.Context, videoURL, outputDir string) (*Result, error) {
+	job, err := s.startJob(ctx, videoURL, outputDir)
+	if err != nil {
+		return nil, err
+	}
+	defer job.close()
+
+	if err := s.downloadStage(ctx, job); err != nil {
+		return nil, err
+	}
+	if err := s.transcribeStage(ctx, job); err != nil {
+		return nil, err
+	}
+	return s.uploadStage(ctx, job)
+}
+
+// transcriptionJob carries one video through the stages of Run and RunPipeline.
+type transcriptionJob struct {
+	videoURL string
+	dir      *jobDir
+	// release frees the disk space reserved for the job.
+	release       func()
+	audioFilePath string
+	videoID       string
+	// audioURL is the archived audio, set by downloadStage when the archive was used.
+	audioURL      string
+	transcription string
+	provenance    Provenance
+}
+
+// startJob runs the pre-flight checks, reserves the job's disk space and creates its private
+// working directory. The caller must close the job, also on panic or cancellation.
+func (s *TranscriptionServiceImpl) startJob(ctx context.Context, videoURL, outputDir string) (*transcriptionJob, error) {
+	// 1. Pre-flight checks; archived audio already passed them when it was first transcribed
+	var meta *MediaMetadata
+	if archivedAudioFrom(ctx) == "" {
+		var err error
+		if meta, err = s.preflight(ctx, videoURL); err != nil {
+			return nil, err
 		}
-		fmt.Printf("Removed temporary audio file: %s\n", audioFilePath)
-	}()
+	}
+	release, err := s.reserveDiskSpace(outputDir, meta)
+	if err != nil {
+		return nil, err
+	}
 
-	// 2. Transcribe the audio
+	// 2. Create a private working directory
+	dir, err := newJobDir(outputDir)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	fmt.Printf("Job %s working directory: %s\n", dir.ID, dir.Path)
+	return &transcriptionJob{videoURL: videoURL, dir: dir, release: release}, nil
+}
+
+// close removes the job's working directory and releases its disk space reservation.
+func (j *transcriptionJob) close() {
+	j.dir.Remove()
+	j.release()
+}
+
+// downloadStage downloads the audio of the job into its working directory.
+func (s *TranscriptionServiceImpl) downloadStage(ctx context.Context, job *transcriptionJob) error {
+	// 3. Download the audio
+	audioFilePath, videoID, audioURL, err := s.download(ctx, job.videoURL, job.dir.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Audio downloaded to: %s\n", audioFilePath)
+	job.audioFilePath, job.videoID, job.audioURL = audioFilePath, videoID, audioURL
+	return nil
+}
+
+// transcribeStage transcribes the downloaded audio.
+func (s *TranscriptionServiceImpl) transcribeStage(ctx context.Context, job *transcriptionJob) error {
+	// 4. Transcribe the audio
 	fmt.Println("Transcribing audio...")
-	transcription, err := s.Transcriber.Transcribe(ctx, audioFilePath)
+	err := s.Retry.Transcribe.Do(ctx, STAGE_TRANSCRIBE, func(ctx context.Context) error {
+		var err error
+		job.transcription, err = s.Transcriber.Transcribe(ctx, job.audioFilePath)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("error transcribing audio: %w", err)
+		return fmt.Errorf("error transcribing audio: %w", err)
 	}
+	job.provenance = Provenance{Model: s.Model, ToolVersions: s.ToolVersions, TranscribedAt: time.Now().UTC()}
+	return nil
+}
 
-	// 3. Determine platform for upload path
+// uploadStage archives the audio, when enabled, and uploads the transcript.
+func (s *TranscriptionServiceImpl) uploadStage(ctx context.Context, job *transcriptionJob) (*Result, error) {
+	// 5. Determine platform for upload path
 	platform := PLATFORM_OTHER
-	if strings.Contains(videoURL, "youtube.com") {
+	if strings.Contains(job.videoURL, "youtube.com") {
 		platform = PLATFORM_YOUTUBE
-	} else if strings.Contains(videoURL, "instagram.com") {
+	} else if strings.Contains(job.videoURL, "instagram.com") {
 		platform = PLATFORM_INSTAGRAM
 	}
+	uploadPath := fmt.Sprintf("%s/%s/%s", APP_NAME, platform, job.videoID)
+
+	// 6. Archive the audio, unless it came from the archive
+	audioURL := job.audioURL
+	if s.AudioEncoder != nil && audioURL == "" {
+		audioURL = s.archiveAudio(ctx, job.audioFilePath, uploadPath)
+	}
+
+	// 7. Upload the transcription, unless it is identical to the stored one
+	sha := ContentSHA256(job.transcription)
+	if stored, ok := unchangedTranscript(ctx, sha); ok {
+		fmt.Printf("Transcript unchanged, skipping upload: %s\n", stored.URL)
+		// Notes are only written again when the stored transcript has none
+		notesURL := stored.NotesURL
+		if s.NotesGenerator != nil && notesURL == "" {
+			notesURL = s.writeNotes(ctx, job.transcription, uploadPath)
+		}
+		return &Result{URL: stored.URL, VideoID: job.videoID, Provenance: job.provenance, SHA256: sha, Unchanged: true, AudioURL: audioURL, NotesURL: notesURL, Transcript: job.transcription}, nil
+	}
 
-	// 4. Upload the transcription
 	fmt.Println("Uploading transcription...")
-	uploadPath := fmt.Sprintf("%s/%s/%s", APP_NAME, platform, videoID)
-	rawResponse, err := s.Uploader.Upload(ctx, transcription, uploadPath)
+	var upload *UploadResult
+	err := s.Retry.Upload.Do(ctx, STAGE_UPLOAD, func(ctx context.Context) error {
+		var err error
+		upload, err = s.Uploader.Upload(ctx, job.transcription, uploadPath)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("error uploading transcription: %w", err)
+		return nil, fmt.Errorf("error uploading transcription: %w", err)
 	}
 
 	fmt.Println("\n--- Transcription Upload Complete ---")
-	var blobResp vercelBlobResponse
-	if jsonErr := json.Unmarshal([]byte(rawResponse), &blobResp); jsonErr == nil {
-		fmt.Printf("Blob URL:  %s\n", blobResp.URL)
-		fmt.Printf("Pathname:  %s\n", blobResp.Pathname)
-		return blobResp.URL, nil
+	fmt.Printf("Blob URL:  %s\n", upload.URL)
+	fmt.Printf("Pathname:  %s\n", upload.Pathname)
+
+	// 8. Write notes on the transcript
+	var notesURL string
+	if s.NotesGenerator != nil {
+		notesURL = s.writeNotes(ctx, job.transcription, uploadPath)
+	}
+	return &Result{URL: upload.URL, VideoID: job.videoID, Provenance: job.provenance, SHA256: sha, AudioURL: audioURL, NotesURL: notesURL, Transcript: job.transcription}, nil
+}
+
+// preflight probes the video metadata, when the downloader supports it, and checks it against s.Limits.
+// It returns a *RejectionError for videos that must not be downloaded, and nil metadata when the
+// downloader cannot probe.
+func (s *TranscriptionServiceImpl) preflight(ctx context.Context, videoURL string) (*MediaMetadata, error) {
+	prober, ok := s.Downloader.(MetadataProber)
+	if !ok {
+		return nil, nil
 	}
 
-	fmt.Println(rawResponse)
-	return rawResponse, nil
+	fmt.Println("Checking video metadata...")
+	var meta *MediaMetadata
+	err := s.Retry.Download.Do(ctx, STAGE_PROBE, func(ctx context.Context) error {
+		var err error
+		meta, err = prober.Probe(ctx, videoURL)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error probing video metadata: %w", err)
+	}
+
+	if err := s.Limits.Check(meta); err != nil {
+		return nil, err
+	}
+	if s.Limits.MaxDuration > 0 && meta.Duration == 0 {
+		fmt.Printf("Warning: the duration of %s is unknown, so the maximum duration of %s is not enforced\n", videoURL, s.Limits.MaxDuration)
+	}
+	fmt.Printf("Video %s passed pre-flight checks (duration: %s)\n", meta.ID, meta.Duration)
+	return meta, nil
 }
@@ -0,0 +1,73 @@
+package src
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Segment is one timed cue of a transcript.
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// ParseSRT parses the SRT transcripts produced by whisper-cli into their segments. Cues without
+// text are dropped; the numeric index line of each cue is optional.
+func ParseSRT(content string) ([]Segment, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	var segments []Segment
+	for block := range strings.SplitSeq(content, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 1 && strings.TrimSpace(lines[0]) == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(lines[0])); err == nil && len(lines) > 1 {
+			lines = lines[1:]
+		}
+		start, end, ok := strings.Cut(lines[0], "-->")
+		if !ok {
+			return nil, fmt.Errorf("invalid SRT cue timing %q", lines[0])
+		}
+		var segment Segment
+		var err error
+		if segment.Start, err = parseSRTTimestamp(start); err != nil {
+			return nil, err
+		}
+		if segment.End, err = parseSRTTimestamp(end); err != nil {
+			return nil, err
+		}
+		parts := make([]string, 0, len(lines)-1)
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				parts = append(parts, line)
+			}
+		}
+		if segment.Text = strings.Join(parts, " "); segment.Text == "" {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// parseSRTTimestamp parses an SRT timestamp such as 00:01:02,345.
+func parseSRTTimestamp(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	var h, m, s, ms int
+	if _, err := fmt.Sscanf(strings.Replace(value, ",", ".", 1), "%d:%d:%d.%d", &h, &m, &s, &ms); err != nil {
+		return 0, fmt.Errorf("invalid SRT timestamp %q", value)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
+
+// SegmentsText returns the plain text of segments, one segment per line.
+func SegmentsText(segments []Segment) string {
+	lines := make([]string, len(segments))
+	for i, segment := range segments {
+		lines[i] = segment.Text
+	}
+	return strings.Join(lines, "\n")
+}
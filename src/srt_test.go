@@ -0,0 +1,42 @@
+package src
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSRT(t *testing.T) {
+	const transcript = "1\r\n00:00:00,000 --> 00:00:02,500\r\n Hello and welcome.\r\n\r\n" +
+		"2\n00:00:02,500 --> 00:01:04,120\n Today we look at\npgxpool.\n\n" +
+		"3\n00:01:04,120 --> 00:01:05,000\n\n\n"
+
+	segments, err := ParseSRT(transcript)
+	if err != nil {
+		t.Fatalf("ParseSRT failed: %v", err)
+	}
+	want := []Segment{
+		{Start: 0, End: 2500 * time.Millisecond, Text: "Hello and welcome."},
+		{Start: 2500 * time.Millisecond, End: time.Minute + 4120*time.Millisecond, Text: "Today we look at pgxpool."},
+	}
+	if !reflect.DeepEqual(segments, want) {
+		t.Errorf("want %+v, got %+v", want, segments)
+	}
+	if text := SegmentsText(segments); text != "Hello and welcome.\nToday we look at pgxpool." {
+		t.Errorf("unexpected text %q", text)
+	}
+}
+
+func TestParseSRT_RejectsInvalidTiming(t *testing.T) {
+	for _, transcript := range []string{
+		"1\nnot a timing\nHello\n",
+		"1\n00:00:00,000 --> soon\nHello\n",
+	} {
+		if _, err := ParseSRT(transcript); err == nil {
+			t.Errorf("expected an error for %q", transcript)
+		}
+	}
+	if segments, err := ParseSRT(""); err != nil || segments != nil {
+		t.Errorf("want no segments for an empty transcript, got %v, %v", segments, err)
+	}
+}
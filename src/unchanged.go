@@ -0,0 +1,36 @@
+package src
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// StoredTranscript is the transcript a job replaces, as recorded in the database.
+type StoredTranscript struct {
+	URL    string
+	SHA256 string
+	// NotesURL is the stored notes on the transcript, kept when the transcript is unchanged.
+	NotesURL string
+}
+
+type storedTranscriptKey struct{}
+
+// WithStoredTranscript returns a context telling Run which transcript is already stored for the job.
+// When the new transcript has the same SHA256, Run skips the upload and reports the stored URL
+// with Result.Unchanged set.
+func WithStoredTranscript(ctx context.Context, stored StoredTranscript) context.Context {
+	return context.WithValue(ctx, storedTranscriptKey{}, stored)
+}
+
+// unchangedTranscript returns the stored transcript from ctx when it has the given hash.
+func unchangedTranscript(ctx context.Context, sha string) (StoredTranscript, bool) {
+	stored, ok := ctx.Value(storedTranscriptKey{}).(StoredTranscript)
+	return stored, ok && stored.URL != "" && stored.SHA256 == sha
+}
+
+// ContentSHA256 returns the hex-encoded SHA-256 of a transcript.
+func ContentSHA256(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,68 @@
+package src
+
+import (
+	"context"
+	"testing"
+)
+
+type stubDownloader struct{}
+
+func (stubDownloader) DownloadAudio(ctx context.Context, videoURL, outputDir string) (string, string, error) {
+	return outputDir + "/abc.wav", "abc", nil
+}
+
+type stubTranscriber struct{ text string }
+
+func (t stubTranscriber) Transcribe(ctx context.Context, audioFilePath string) (string, error) {
+	return t.text, nil
+}
+
+// countingUploader counts the uploads and keeps their content by filename.
+type countingUploader struct {
+	uploads int
+	files   map[string]string
+}
+
+func (u *countingUploader) Upload(ctx context.Context, content, filename string) (*UploadResult, error) {
+	u.uploads++
+	if u.files == nil {
+		u.files = map[string]string{}
+	}
+	u.files[filename] = content
+	return &UploadResult{URL: "https://blob.example.com/" + filename, Pathname: filename}, nil
+}
+
+func TestRun_SkipsUploadOfUnchangedTranscript(t *testing.T) {
+	const transcript = "1\n00:00:00,000 --> 00:00:01,000\nHello\n"
+	uploader := &countingUploader{}
+	svc := NewTranscriptionService(stubDownloader{}, stubTranscriber{transcript}, uploader)
+
+	stored := StoredTranscript{URL: "https://blob.example.com/old", SHA256: ContentSHA256(transcript)}
+	result, err := svc.Run(WithStoredTranscript(context.Background(), stored), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if uploader.uploads != 0 {
+		t.Errorf("expected no upload, got %d", uploader.uploads)
+	}
+	if !result.Unchanged || result.URL != stored.URL || result.SHA256 != stored.SHA256 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestRun_UploadsChangedTranscript(t *testing.T) {
+	uploader := &countingUploader{}
+	svc := NewTranscriptionService(stubDownloader{}, stubTranscriber{"new text"}, uploader)
+
+	stored := StoredTranscript{URL: "https://blob.example.com/old", SHA256: ContentSHA256("old text")}
+	result, err := svc.Run(WithStoredTranscript(context.Background(), stored), "https://www.youtube.com/watch?v=abc", t.TempDir())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if uploader.uploads != 1 {
+		t.Errorf("expected one upload, got %d", uploader.uploads)
+	}
+	if result.Unchanged || result.URL != "https://blob.example.com/yt-transcribe/youtube/abc" || result.SHA256 != ContentSHA256("new text") {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
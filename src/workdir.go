@@ -0,0 +1,140 @@
+package src
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// JOB_DIR_PREFIX prefixes the per-job working directories created inside the output directory.
+	JOB_DIR_PREFIX = "yt-transcribe-job-"
+	// JOB_DIR_HEARTBEAT is how often a running job refreshes the modification time of its directory.
+	JOB_DIR_HEARTBEAT = time.Minute
+	// JOB_DIR_MAX_AGE is how long a directory may go without a heartbeat before a sweep treats it as orphaned.
+	JOB_DIR_MAX_AGE = 30 * time.Minute
+)
+
+// newJobID returns a random identifier used to name a job's working directory.
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// jobDir is the private working directory of a single job. Two jobs never share one,
+// so concurrent jobs for the same video cannot overwrite or delete each other's files.
+type jobDir struct {
+	ID   string
+	Path string
+	stop chan struct{}
+}
+
+// newJobDir creates a uniquely named working directory inside outputDir and starts a heartbeat
+// that keeps it from being swept while the job runs. Callers must defer Remove.
+func newJobDir(outputDir string) (*jobDir, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(outputDir, JOB_DIR_PREFIX+id)
+	if err := os.Mkdir(path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job directory %s: %w", path, err)
+	}
+
+	d := &jobDir{ID: id, Path: path, stop: make(chan struct{})}
+	go d.heartbeat()
+	return d, nil
+}
+
+func (d *jobDir) heartbeat() {
+	ticker := time.NewTicker(JOB_DIR_HEARTBEAT)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case now := <-ticker.C:
+			_ = os.Chtimes(d.Path, now, now)
+		}
+	}
+}
+
+// Remove stops the heartbeat and deletes the directory with everything in it.
+func (d *jobDir) Remove() {
+	close(d.stop)
+	if err := os.RemoveAll(d.Path); err != nil {
+		log.Printf("Warning: could not remove job directory %s: %v", d.Path, err)
+		return
+	}
+	fmt.Printf("Removed job directory: %s\n", d.Path)
+}
+
+// SweepStaleDirs removes directories directly inside dir whose name starts with one of prefixes and
+// that have not been modified for maxAge, neither themselves nor any directory directly inside them.
+// It is meant to run at startup to clean up after crashed runs, and returns the removed paths.
+func SweepStaleDirs(dir string, maxAge time.Duration, prefixes ...string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var removed []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !hasAnyPrefix(entry.Name(), prefixes) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if lastActivity(path).After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			log.Printf("Warning: could not remove orphaned directory %s: %v", path, err)
+			continue
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// lastActivity returns the newest modification time of path and the directories directly inside it,
+// so a wrapper directory (e.g. one created per API request) stays alive while its job heartbeats.
+func lastActivity(path string) time.Time {
+	var latest time.Time
+	if info, err := os.Stat(path); err == nil {
+		latest = info.ModTime()
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return latest
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewJobDir_CreatesUniqueDirectories(t *testing.T) {
+	outputDir := t.TempDir()
+
+	first, err := newJobDir(outputDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := newJobDir(outputDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.Path == second.Path {
+		t.Fatalf("expected distinct job directories, both are %s", first.Path)
+	}
+	if filepath.Dir(first.Path) != outputDir {
+		t.Errorf("expected job directory inside %s, got %s", outputDir, first.Path)
+	}
+
+	if err := os.WriteFile(filepath.Join(first.Path, "abc.wav"), []byte("audio"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	first.Remove()
+
+	if _, err := os.Stat(first.Path); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got err=%v", first.Path, err)
+	}
+	if _, err := os.Stat(second.Path); err != nil {
+		t.Errorf("expected %s to be left alone, got err=%v", second.Path, err)
+	}
+	second.Remove()
+}
+
+func TestNewJobDir_RemovedOnPanic(t *testing.T) {
+	outputDir := t.TempDir()
+	var path string
+
+	func() {
+		defer func() { _ = recover() }()
+
+		job, err := newJobDir(outputDir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer job.Remove()
+		path = job.Path
+		panic("boom")
+	}()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed after panic, got err=%v", path, err)
+	}
+}
+
+func TestSweepStaleDirs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+
+	mkdir := func(name string, modTime time.Time) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", path, err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set times on %s: %v", path, err)
+		}
+		return path
+	}
+
+	stale := mkdir(JOB_DIR_PREFIX+"stale", old)
+	fresh := mkdir(JOB_DIR_PREFIX+"fresh", time.Now())
+	unrelated := mkdir("something-else", old)
+	wrapper := mkdir("api-request", old)
+	mkdir("api-request/"+JOB_DIR_PREFIX+"active", time.Now())
+	if err := os.Chtimes(wrapper, old, old); err != nil {
+		t.Fatalf("failed to set times on %s: %v", wrapper, err)
+	}
+
+	removed, err := SweepStaleDirs(dir, time.Hour, JOB_DIR_PREFIX, "api-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(removed) != 1 || removed[0] != stale {
+		t.Errorf("expected only %s to be removed, got %v", stale, removed)
+	}
+	for _, path := range []string{fresh, unrelated, wrapper} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept, got err=%v", path, err)
+		}
+	}
+}